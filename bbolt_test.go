@@ -11,6 +11,7 @@ func TestBBolt(t *testing.T) {
 	if e != nil {
 		t.Fatal(e)
 	}
+	defer store.Close()
 	e = store.Reset()
 	if e != nil {
 		t.Fatal(e)
@@ -22,6 +23,7 @@ func TestBBoltRefresh(t *testing.T) {
 	if e != nil {
 		t.Fatal(e)
 	}
+	defer store.Close()
 	e = store.Reset()
 	if e != nil {
 		t.Fatal(e)
@@ -29,3 +31,15 @@ func TestBBoltRefresh(t *testing.T) {
 
 	testManagerRefresh(t, store)
 }
+func TestBBoltList(t *testing.T) {
+	store, e := bbolt.New(bbolt.WithLimit(100))
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer store.Close()
+	e = store.Reset()
+	if e != nil {
+		t.Fatal(e)
+	}
+	testManagerList(t, store)
+}
@@ -0,0 +1,143 @@
+package sessionstore
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"net"
+	"strings"
+
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
+)
+
+// 請求 session 的客戶端信息，來自 Put Get Refresh 的 MetadataOption
+type Client struct {
+	IP        string
+	UserAgent string
+	Device    string
+	// 調用者提供的綁定值
+	Value string
+}
+
+func newClient(opt []MetadataOption) *Client {
+	opts := newMetadataOptions(opt)
+	return &Client{
+		IP:        opts.ip,
+		UserAgent: opts.userAgent,
+		Device:    opts.device,
+		Value:     opts.value,
+	}
+}
+
+// 設置調用者提供的綁定值，例如 TLS 客戶端證書的指紋，配合 BindValue 使用
+func WithBindingValue(value string) MetadataOption {
+	return newFuncMetadataOption(func(o *metadataOptions) {
+		o.value = value
+	})
+}
+
+// 計算客戶端指紋，Put 時記錄的指紋與 Get Refresh 時計算的指紋不同則 session 被其它客戶端使用
+type Binding func(client *Client) (fingerprint string)
+
+// 以客戶端 ip 所在的網段作爲指紋，ipv4 和 ipv6 分別爲兩種地址保留的前綴位數，例如 24 和 64
+func BindIPPrefix(ipv4, ipv6 int) Binding {
+	return func(client *Client) string {
+		ip := net.ParseIP(client.IP)
+		if ip == nil {
+			return ``
+		} else if ip4 := ip.To4(); ip4 != nil {
+			return ip4.Mask(net.CIDRMask(ipv4, 32)).String()
+		}
+		return ip.Mask(net.CIDRMask(ipv6, 128)).String()
+	}
+}
+
+// 以客戶端 User-Agent 的 hash 作爲指紋
+func BindUserAgent() Binding {
+	return func(client *Client) string {
+		return bindingHash(client.UserAgent)
+	}
+}
+
+// 以 WithBindingValue 提供的值的 hash 作爲指紋
+func BindValue() Binding {
+	return func(client *Client) string {
+		return bindingHash(client.Value)
+	}
+}
+
+// 組合多個指紋，所有指紋都相同才認爲是同一個客戶端
+func Bindings(bindings ...Binding) Binding {
+	return func(client *Client) string {
+		strs := make([]string, len(bindings))
+		for i, binding := range bindings {
+			strs[i] = binding(client)
+		}
+		return strings.Join(strs, `|`)
+	}
+}
+func bindingHash(value string) string {
+	sum := sha256.Sum256(StringToBytes(value))
+	return base64.RawURLEncoding.EncodeToString(sum[:16])
+}
+
+// 客戶端指紋不匹配時的處理方式
+type BindingDecision int
+
+const (
+	// 拒絕請求並返回 cryptoer.ErrBindingNotMatched
+	BindingDeny BindingDecision = iota
+	// 允許請求
+	BindingAllow
+	// 刪除 session 並返回 cryptoer.ErrReauthRequired，客戶端需要重新登入
+	BindingReauth
+)
+
+// 客戶端指紋不匹配時調用，metadata 爲 session 創建時記錄的元數據，client 爲當前請求的客戶端
+type BindingPolicy func(ctx context.Context, metadata *Metadata, client *Client) BindingDecision
+
+// 返回 Put 時需要記錄的客戶端指紋
+func (m *Manager) fingerprint(opt []MetadataOption) string {
+	if m.opts.binding == nil {
+		return ``
+	}
+	return m.opts.binding(newClient(opt))
+}
+
+// 檢查當前客戶端是否與 session 綁定的客戶端相同，
+// 返回 nil cryptoer.ErrBindingNotMatched 或 cryptoer.ErrReauthRequired
+func (m *Manager) verifyBinding(ctx context.Context, md *protoc_session.Metadata, opt []MetadataOption) (e error) {
+	if m.opts.binding == nil || md == nil {
+		return
+	}
+	client := newClient(opt)
+	if m.opts.binding(client) == md.Binding {
+		return
+	}
+	decision := BindingDeny
+	if m.opts.policy != nil {
+		decision = m.opts.policy(ctx, newMetadata(md, 0), client)
+	}
+	switch decision {
+	case BindingAllow:
+	case BindingReauth:
+		e = cryptoer.ErrReauthRequired
+	default:
+		e = cryptoer.ErrBindingNotMatched
+	}
+	return
+}
+
+// 客戶端需要重新登入，刪除 session
+func (m *Manager) reauth(ctx context.Context, key string, st *protoc_session.Stateless) (e error) {
+	if st != nil {
+		e = m.statelessRevoke(ctx, encodeKey(st.Id, st.Platform))
+	} else {
+		e = m.opts.store.Del(ctx, key)
+	}
+	if e == nil {
+		e = cryptoer.ErrReauthRequired
+	}
+	return
+}
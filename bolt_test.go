@@ -11,6 +11,7 @@ func TestBolt(t *testing.T) {
 	if e != nil {
 		t.Fatal(e)
 	}
+	defer store.Close()
 	e = store.Reset()
 	if e != nil {
 		t.Fatal(e)
@@ -22,6 +23,7 @@ func TestBoltRefresh(t *testing.T) {
 	if e != nil {
 		t.Fatal(e)
 	}
+	defer store.Close()
 	e = store.Reset()
 	if e != nil {
 		t.Fatal(e)
@@ -29,3 +31,15 @@ func TestBoltRefresh(t *testing.T) {
 
 	testManagerRefresh(t, store)
 }
+func TestBoltList(t *testing.T) {
+	store, e := bolt.New(bolt.WithLimit(100))
+	if e != nil {
+		t.Fatal(e)
+	}
+	defer store.Close()
+	e = store.Reset()
+	if e != nil {
+		t.Fatal(e)
+	}
+	testManagerList(t, store)
+}
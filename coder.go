@@ -1,6 +1,108 @@
 package sessionstore
 
+import (
+	"strings"
+	"sync"
+)
+
 type Coder interface {
 	Unmarshal(b []byte) (session interface{}, e error)
 	Marshal(session interface{}) (b []byte, e error)
 }
+
+// 有註冊名稱的 Coder，名稱可以在配置中使用 NewCoder 引用
+type NamedCoder interface {
+	Coder
+	Name() string
+}
+
+// 可以創建解碼用 session 的 Coder，Manager 依據存儲的 Coder 名稱解碼舊數據時需要它
+type SessionFactory interface {
+	// 返回一個用於解碼 session 的新指針
+	NewSession() interface{}
+}
+
+// 創建 Coder，newSession 返回一個用於解碼 session 的新指針
+type CoderFactory func(newSession func() interface{}) NamedCoder
+
+// 包裝 Coder，例如爲其添加壓縮
+type CoderWrapper func(coder NamedCoder) NamedCoder
+
+var coders = struct {
+	factories map[string]CoderFactory
+	wrappers  map[string]CoderWrapper
+	rw        sync.RWMutex
+}{
+	factories: map[string]CoderFactory{
+		`json`: func(newSession func() interface{}) NamedCoder {
+			return jsonCoder{newSession: newSession}
+		},
+		`gob`: func(newSession func() interface{}) NamedCoder {
+			return gobCoder{newSession: newSession}
+		},
+		`proto`: func(newSession func() interface{}) NamedCoder {
+			return protoCoder{newSession: newSession}
+		},
+	},
+	wrappers: map[string]CoderWrapper{
+		`gzip`: NewGzipCoder,
+	},
+}
+
+// 註冊一個 Coder，如果 name 已經被註冊則 panic
+func RegisterCoder(name string, factory CoderFactory) {
+	coders.rw.Lock()
+	defer coders.rw.Unlock()
+	if name == `` || strings.Contains(name, `+`) {
+		panic(`sessionstore: invalid coder name ` + name)
+	} else if _, ok := coders.factories[name]; ok {
+		panic(`sessionstore: RegisterCoder called twice for coder ` + name)
+	}
+	coders.factories[name] = factory
+}
+
+// 註冊一個 Coder 包裝器，如果 name 已經被註冊則 panic
+func RegisterCoderWrapper(name string, wrapper CoderWrapper) {
+	coders.rw.Lock()
+	defer coders.rw.Unlock()
+	if name == `` || strings.Contains(name, `+`) {
+		panic(`sessionstore: invalid coder wrapper name ` + name)
+	} else if _, ok := coders.wrappers[name]; ok {
+		panic(`sessionstore: RegisterCoderWrapper called twice for wrapper ` + name)
+	}
+	coders.wrappers[name] = wrapper
+}
+
+// 依據註冊名稱創建 Coder，name 的格式爲 coder[+wrapper...]，例如 json+gzip。
+// 內置的 Coder 有 json gob proto，內置的包裝器有 gzip
+func NewCoder(name string, newSession func() interface{}) (coder NamedCoder, e error) {
+	strs := strings.Split(name, `+`)
+	coders.rw.RLock()
+	defer coders.rw.RUnlock()
+	factory, ok := coders.factories[strs[0]]
+	if !ok {
+		e = ErrUnknownCoder
+		return
+	}
+	wrappers := make([]CoderWrapper, len(strs)-1)
+	for i, str := range strs[1:] {
+		wrappers[i], ok = coders.wrappers[str]
+		if !ok {
+			e = ErrUnknownCoder
+			return
+		}
+	}
+	coder = factory(newSession)
+	for _, wrapper := range wrappers {
+		coder = wrapper(coder)
+	}
+	return
+}
+
+// 依據註冊名稱創建解碼 session 爲 *T 的 Coder
+func NewCoderFor[T any](name string) (coder NamedCoder, e error) {
+	coder, e = NewCoder(name, func() interface{} {
+		return new(T)
+	})
+	return
+}
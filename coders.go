@@ -0,0 +1,193 @@
+package sessionstore
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/gob"
+	"encoding/json"
+	"io"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type jsonCoder struct {
+	newSession func() interface{}
+}
+
+// 返回使用 encoding/json 編碼 session 的 Coder，解碼的 session 爲 *T
+func NewJSONCoder[T any]() NamedCoder {
+	return jsonCoder{
+		newSession: func() interface{} {
+			return new(T)
+		},
+	}
+}
+func (jsonCoder) Name() string {
+	return `json`
+}
+func (c jsonCoder) NewSession() interface{} {
+	return c.newSession()
+}
+func (c jsonCoder) Unmarshal(b []byte) (session interface{}, e error) {
+	v := c.newSession()
+	e = json.Unmarshal(b, v)
+	if e != nil {
+		return
+	}
+	session = v
+	return
+}
+func (jsonCoder) Marshal(session interface{}) (b []byte, e error) {
+	b, e = json.Marshal(session)
+	return
+}
+
+type gobCoder struct {
+	newSession func() interface{}
+}
+
+// 返回使用 encoding/gob 編碼 session 的 Coder，解碼的 session 爲 *T
+func NewGobCoder[T any]() NamedCoder {
+	return gobCoder{
+		newSession: func() interface{} {
+			return new(T)
+		},
+	}
+}
+func (gobCoder) Name() string {
+	return `gob`
+}
+func (c gobCoder) NewSession() interface{} {
+	return c.newSession()
+}
+func (c gobCoder) Unmarshal(b []byte) (session interface{}, e error) {
+	v := c.newSession()
+	e = gob.NewDecoder(bytes.NewReader(b)).Decode(v)
+	if e != nil {
+		return
+	}
+	session = v
+	return
+}
+func (gobCoder) Marshal(session interface{}) (b []byte, e error) {
+	var buffer bytes.Buffer
+	e = gob.NewEncoder(&buffer).Encode(session)
+	if e != nil {
+		return
+	}
+	b = buffer.Bytes()
+	return
+}
+
+type protoCoder struct {
+	newSession func() interface{}
+}
+
+// 返回使用 protobuf 編碼 session 的 Coder，解碼的 session 爲 *T
+func NewProtoCoder[T any, P interface {
+	*T
+	proto.Message
+}]() NamedCoder {
+	return protoCoder{
+		newSession: func() interface{} {
+			return P(new(T))
+		},
+	}
+}
+func (protoCoder) Name() string {
+	return `proto`
+}
+func (c protoCoder) NewSession() interface{} {
+	return c.newSession()
+}
+func (c protoCoder) Unmarshal(b []byte) (session interface{}, e error) {
+	v, ok := c.newSession().(proto.Message)
+	if !ok {
+		e = ErrSessionType
+		return
+	}
+	e = proto.Unmarshal(b, v)
+	if e != nil {
+		return
+	}
+	session = v
+	return
+}
+func (protoCoder) Marshal(session interface{}) (b []byte, e error) {
+	v, ok := session.(proto.Message)
+	if !ok {
+		e = ErrSessionType
+		return
+	}
+	b, e = proto.Marshal(v)
+	return
+}
+
+// gzip 解壓後 session 的默認最大長度
+const DefaultGzipLimit = 1024 * 1024
+
+type gzipCoder struct {
+	coder NamedCoder
+	limit int64
+}
+
+// 返回使用 gzip 壓縮 coder 編碼結果的 Coder，名稱爲 coder.Name()+"+gzip"，
+// 解壓後超過 DefaultGzipLimit 字節時返回 ErrSessionTooLarge
+func NewGzipCoder(coder NamedCoder) NamedCoder {
+	return NewGzipCoderLimit(coder, DefaultGzipLimit)
+}
+
+// 返回使用 gzip 壓縮 coder 編碼結果的 Coder，解壓後超過 limit 字節時返回 ErrSessionTooLarge，
+// 以避免壓縮炸彈耗盡內存。limit 小於等於 0 時使用 DefaultGzipLimit
+func NewGzipCoderLimit(coder NamedCoder, limit int64) NamedCoder {
+	if limit <= 0 {
+		limit = DefaultGzipLimit
+	}
+	return gzipCoder{
+		coder: coder,
+		limit: limit,
+	}
+}
+func (c gzipCoder) Name() string {
+	return c.coder.Name() + `+gzip`
+}
+func (c gzipCoder) NewSession() interface{} {
+	if f, ok := c.coder.(SessionFactory); ok {
+		return f.NewSession()
+	}
+	return nil
+}
+func (c gzipCoder) Unmarshal(b []byte) (session interface{}, e error) {
+	r, e := gzip.NewReader(bytes.NewReader(b))
+	if e != nil {
+		return
+	}
+	defer r.Close()
+	b, e = io.ReadAll(io.LimitReader(r, c.limit+1))
+	if e != nil {
+		return
+	} else if int64(len(b)) > c.limit {
+		e = ErrSessionTooLarge
+		return
+	}
+	session, e = c.coder.Unmarshal(b)
+	return
+}
+func (c gzipCoder) Marshal(session interface{}) (b []byte, e error) {
+	b, e = c.coder.Marshal(session)
+	if e != nil {
+		return
+	}
+	var buffer bytes.Buffer
+	w := gzip.NewWriter(&buffer)
+	_, e = w.Write(b)
+	if e != nil {
+		return
+	}
+	e = w.Close()
+	if e != nil {
+		return
+	}
+	b = buffer.Bytes()
+	return
+}
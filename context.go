@@ -0,0 +1,37 @@
+package sessionstore
+
+import "context"
+
+type contextKey struct{}
+
+type contextValue struct {
+	token   *Token
+	session interface{}
+}
+
+// 返回保存了 token 和 session 的 context，http 和 grpc 中間件使用它傳遞驗證結果
+func NewContext(ctx context.Context, token *Token, session interface{}) context.Context {
+	return context.WithValue(ctx, contextKey{}, contextValue{
+		token:   token,
+		session: session,
+	})
+}
+
+// 返回保存在 context 中的 token 和 session
+func FromContext(ctx context.Context) (token *Token, session interface{}, ok bool) {
+	v, ok := ctx.Value(contextKey{}).(contextValue)
+	if ok {
+		token = v.token
+		session = v.session
+	}
+	return
+}
+
+// 返回保存在 context 中的 *T 類型 session
+func SessionFromContext[T any](ctx context.Context) (session *T, ok bool) {
+	_, v, ok := FromContext(ctx)
+	if ok {
+		session, ok = v.(*T)
+	}
+	return
+}
@@ -0,0 +1,127 @@
+package cryptoer
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"math/big"
+)
+
+// Implements the ECDSA family of signing methods
+// Expects a PEM or DER encoded private key for signing and public key for validation
+type SigningMethodECDSA struct {
+	Name      string
+	Hash      crypto.Hash
+	KeySize   int
+	CurveBits int
+}
+
+// Specific instances for EC256 and company
+var (
+	SigningMethodES256 *SigningMethodECDSA
+	SigningMethodES384 *SigningMethodECDSA
+	SigningMethodES512 *SigningMethodECDSA
+)
+
+func init() {
+	// ES256
+	SigningMethodES256 = &SigningMethodECDSA{"ES256", crypto.SHA256, 32, 256}
+	RegisterSigningMethod(SigningMethodES256.Alg(), func() SigningMethod {
+		return SigningMethodES256
+	})
+
+	// ES384
+	SigningMethodES384 = &SigningMethodECDSA{"ES384", crypto.SHA384, 48, 384}
+	RegisterSigningMethod(SigningMethodES384.Alg(), func() SigningMethod {
+		return SigningMethodES384
+	})
+
+	// ES512
+	SigningMethodES512 = &SigningMethodECDSA{"ES512", crypto.SHA512, 66, 521}
+	RegisterSigningMethod(SigningMethodES512.Alg(), func() SigningMethod {
+		return SigningMethodES512
+	})
+}
+
+func (m *SigningMethodECDSA) Alg() string {
+	return m.Name
+}
+
+// Verify the signature of ESXXX tokens.  Returns nil if the signature is valid.
+func (m *SigningMethodECDSA) Verify(key, value []byte, signature string) error {
+	return m.VerifyKey(NewKey(key), value, signature)
+}
+
+// Verify the signature of ESXXX tokens with a parsed key.
+// The key curve must match the method (e.g. ES256 rejects a P-384 key).
+func (m *SigningMethodECDSA) VerifyKey(key *Key, value []byte, signature string) error {
+	// Decode the signature
+	sig, err := DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	pub, err := key.PublicKey()
+	if err != nil {
+		return err
+	}
+	ecdsaKey, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if ecdsaKey.Curve.Params().BitSize != m.CurveBits {
+		return ErrInvalidKey
+	}
+	if len(sig) != 2*m.KeySize {
+		return ErrSignatureInvalid
+	}
+	r := big.NewInt(0).SetBytes(sig[:m.KeySize])
+	s := big.NewInt(0).SetBytes(sig[m.KeySize:])
+
+	// Can we use the specified hashing method?
+	if !m.Hash.Available() {
+		return ErrHashUnavailable
+	}
+	hasher := m.Hash.New()
+	hasher.Write(value)
+
+	if !ecdsa.Verify(ecdsaKey, hasher.Sum(nil), r, s) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// Implements the Sign method from SigningMethod for this signing method.
+// The signature is the fixed size big-endian R || S as required by JWS
+func (m *SigningMethodECDSA) Sign(key, value []byte) (string, error) {
+	return m.SignKey(NewKey(key), value)
+}
+
+// Implements the SignKey method from KeySigningMethod for this signing method.
+func (m *SigningMethodECDSA) SignKey(key *Key, value []byte) (string, error) {
+	signer, err := key.Signer()
+	if err != nil {
+		return "", err
+	}
+	ecdsaKey, ok := signer.(*ecdsa.PrivateKey)
+	if !ok {
+		return "", ErrInvalidKeyType
+	}
+	if ecdsaKey.Curve.Params().BitSize != m.CurveBits {
+		return "", ErrInvalidKey
+	}
+
+	if !m.Hash.Available() {
+		return "", ErrHashUnavailable
+	}
+	hasher := m.Hash.New()
+	hasher.Write(value)
+
+	r, s, err := ecdsa.Sign(rand.Reader, ecdsaKey, hasher.Sum(nil))
+	if err != nil {
+		return "", err
+	}
+	out := make([]byte, 2*m.KeySize)
+	r.FillBytes(out[:m.KeySize])
+	s.FillBytes(out[m.KeySize:])
+	return EncodeSegment(out), nil
+}
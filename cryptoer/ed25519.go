@@ -0,0 +1,71 @@
+package cryptoer
+
+import (
+	"crypto/ed25519"
+)
+
+// Implements the EdDSA family of signing methods
+// Expects a PEM or DER encoded private key for signing and public key for validation
+type SigningMethodEd25519 struct{}
+
+// Specific instance for EdDSA
+var (
+	SigningMethodEdDSA *SigningMethodEd25519
+)
+
+func init() {
+	SigningMethodEdDSA = &SigningMethodEd25519{}
+	RegisterSigningMethod(SigningMethodEdDSA.Alg(), func() SigningMethod {
+		return SigningMethodEdDSA
+	})
+}
+
+func (m *SigningMethodEd25519) Alg() string {
+	return "EdDSA"
+}
+
+// Verify the signature of EdDSA tokens.  Returns nil if the signature is valid.
+func (m *SigningMethodEd25519) Verify(key, value []byte, signature string) error {
+	return m.VerifyKey(NewKey(key), value, signature)
+}
+
+// Verify the signature of EdDSA tokens with a parsed key.
+func (m *SigningMethodEd25519) VerifyKey(key *Key, value []byte, signature string) error {
+	sig, err := DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	pub, err := key.PublicKey()
+	if err != nil {
+		return err
+	}
+	ed25519Key, ok := pub.(ed25519.PublicKey)
+	if !ok {
+		return ErrInvalidKeyType
+	}
+	if len(ed25519Key) != ed25519.PublicKeySize {
+		return ErrInvalidKey
+	}
+	if !ed25519.Verify(ed25519Key, value, sig) {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// Implements the Sign method from SigningMethod for this signing method.
+func (m *SigningMethodEd25519) Sign(key, value []byte) (string, error) {
+	return m.SignKey(NewKey(key), value)
+}
+
+// Implements the SignKey method from KeySigningMethod for this signing method.
+func (m *SigningMethodEd25519) SignKey(key *Key, value []byte) (string, error) {
+	signer, err := key.Signer()
+	if err != nil {
+		return "", err
+	}
+	ed25519Key, ok := signer.(ed25519.PrivateKey)
+	if !ok {
+		return "", ErrInvalidKeyType
+	}
+	return EncodeSegment(ed25519.Sign(ed25519Key, value)), nil
+}
@@ -4,9 +4,26 @@ import "errors"
 
 var (
 	ErrHashUnavailable        = errors.New(`the requested hash function is unavailable`)
+	ErrInvalidKey             = errors.New(`key is invalid`)
+	ErrInvalidKeyType         = errors.New(`key is of invalid type`)
 	ErrInvalidToken           = errors.New(`token invalid`)
+	ErrInvalidID              = errors.New(`generated id invalid`)
 	ErrNotExistsToken         = errors.New(`token not exists`)
 	ErrExpired                = errors.New(`token expired`)
+	ErrIdleTimeout            = errors.New(`session idle timeout`)
 	ErrRefreshTokenNotMatched = errors.New(`refresh token not matched`)
+	ErrRefreshTokenReused     = errors.New(`refresh token reused`)
 	ErrCannotRefresh          = errors.New(`cannot refresh`)
+	ErrTooManySessions        = errors.New(`too many sessions`)
+	ErrConflict               = errors.New(`session modified concurrently`)
+	ErrNotSupported           = errors.New(`not supported`)
+	ErrInvalidKeyID           = errors.New(`invalid key id`)
+	ErrKeyExists              = errors.New(`key already exists`)
+	ErrKeyNotFound            = errors.New(`key not found`)
+	ErrKeyRetired             = errors.New(`key retired`)
+	ErrKeyCurrent             = errors.New(`cannot retire or remove current key`)
+	ErrKeyCannotSign          = errors.New(`key cannot sign`)
+	ErrCSRFTokenNotMatched    = errors.New(`csrf token not matched`)
+	ErrBindingNotMatched      = errors.New(`session bound to another client`)
+	ErrReauthRequired         = errors.New(`reauthentication required`)
 )
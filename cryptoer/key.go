@@ -0,0 +1,114 @@
+package cryptoer
+
+import (
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"sync"
+)
+
+// 簽名或驗證密鑰，第一次使用時解析並緩存結果。
+// 緩存屬於 Key 實例，由 KeyRing 或 Manager 持有，隨密鑰一起被釋放
+type Key struct {
+	b []byte
+
+	privateOnce sync.Once
+	signer      crypto.Signer
+	privateErr  error
+
+	publicOnce sync.Once
+	pub        crypto.PublicKey
+	publicErr  error
+}
+
+func NewKey(key []byte) *Key {
+	return &Key{b: key}
+}
+
+// 返回原始的密鑰數據
+func (k *Key) Bytes() []byte {
+	if k == nil {
+		return nil
+	}
+	return k.b
+}
+
+// 返回解析後的私鑰
+func (k *Key) Signer() (crypto.Signer, error) {
+	if k == nil {
+		return nil, ErrInvalidKey
+	}
+	k.privateOnce.Do(func() {
+		k.signer, k.privateErr = ParsePrivateKey(k.b)
+	})
+	return k.signer, k.privateErr
+}
+
+// 返回解析後的公鑰
+func (k *Key) PublicKey() (crypto.PublicKey, error) {
+	if k == nil {
+		return nil, ErrInvalidKey
+	}
+	k.publicOnce.Do(func() {
+		k.pub, k.publicErr = ParsePublicKey(k.b)
+	})
+	return k.pub, k.publicErr
+}
+
+// 解析 PEM 或 DER 編碼的私鑰，支持 PKCS8 PKCS1 和 SEC1 格式
+func ParsePrivateKey(key []byte) (signer crypto.Signer, e error) {
+	v, e := parsePrivateKey(decodePEM(key))
+	if e != nil {
+		return
+	}
+	signer, ok := v.(crypto.Signer)
+	if !ok {
+		e = ErrInvalidKey
+		return
+	}
+	return
+}
+func parsePrivateKey(der []byte) (interface{}, error) {
+	if key, e := x509.ParsePKCS8PrivateKey(der); e == nil {
+		return key, nil
+	}
+	if key, e := x509.ParsePKCS1PrivateKey(der); e == nil {
+		return key, nil
+	}
+	if key, e := x509.ParseECPrivateKey(der); e == nil {
+		return key, nil
+	}
+	return nil, ErrInvalidKey
+}
+
+// 解析 PEM 或 DER 編碼的公鑰，支持 PKIX PKCS1 格式和證書，傳入私鑰時返回其公鑰
+func ParsePublicKey(key []byte) (pub crypto.PublicKey, e error) {
+	pub, e = parsePublicKey(decodePEM(key))
+	if e != nil {
+		signer, err := ParsePrivateKey(key)
+		if err != nil {
+			return
+		}
+		pub, e = signer.Public(), nil
+	}
+	return
+}
+func parsePublicKey(der []byte) (crypto.PublicKey, error) {
+	if key, e := x509.ParsePKIXPublicKey(der); e == nil {
+		return key, nil
+	}
+	if key, e := x509.ParsePKCS1PublicKey(der); e == nil {
+		return key, nil
+	}
+	if cert, e := x509.ParseCertificate(der); e == nil {
+		return cert.PublicKey, nil
+	}
+	return nil, ErrInvalidKey
+}
+func decodePEM(key []byte) []byte {
+	block, _ := pem.Decode(key)
+	if block == nil {
+		return key
+	}
+	return block.Bytes
+}
@@ -0,0 +1,170 @@
+package cryptoer
+
+import "sync"
+
+type ringKey struct {
+	// 簽名密鑰，如果爲 nil 則只能用於驗證
+	key *Key
+	// 驗證密鑰
+	verify  *Key
+	retired bool
+}
+
+// 簽名密鑰環，每個密鑰有一個 id 會被寫入 token，
+// 新 token 使用當前密鑰簽名，驗證時接受所有未退役的密鑰，
+// 可以在運行時 添加 切換 退役 密鑰 以實現密鑰輪換
+type KeyRing struct {
+	current string
+	keys    map[string]*ringKey
+	rw      sync.RWMutex
+}
+
+func NewKeyRing() *KeyRing {
+	return &KeyRing{
+		keys: make(map[string]*ringKey),
+	}
+}
+
+// 返回 id 是否可以作爲密鑰 id 寫入 token，只允許 RawURLBase64 字符集
+func IsValidKeyID(id string) bool {
+	if id == `` {
+		return false
+	}
+	for _, r := range id {
+		if !(r >= 'a' && r <= 'z' ||
+			r >= 'A' && r <= 'Z' ||
+			r >= '0' && r <= '9' ||
+			r == '-' || r == '_') {
+			return false
+		}
+	}
+	return true
+}
+
+// 添加一個密鑰，如果密鑰環中還沒有當前密鑰則將其設置爲當前密鑰
+func (r *KeyRing) Add(id string, key []byte) (e error) {
+	e = r.AddPair(id, key, key)
+	return
+}
+
+// 添加一組 簽名密鑰 和 驗證密鑰，用於非對稱簽名算法(例如 私鑰 和 公鑰)。
+// 如果 key 爲 nil 則只用於驗證，這允許只持有公鑰的服務驗證 token
+func (r *KeyRing) AddPair(id string, key, verify []byte) (e error) {
+	if !IsValidKeyID(id) {
+		e = ErrInvalidKeyID
+		return
+	}
+	r.rw.Lock()
+	defer r.rw.Unlock()
+	if _, ok := r.keys[id]; ok {
+		e = ErrKeyExists
+		return
+	}
+	k := &ringKey{
+		verify: NewKey(verify),
+	}
+	if key != nil {
+		k.key = NewKey(key)
+	}
+	r.keys[id] = k
+	if r.current == `` && key != nil {
+		r.current = id
+	}
+	return
+}
+
+// 設置用於簽名新 token 的密鑰
+func (r *KeyRing) SetCurrent(id string) (e error) {
+	r.rw.Lock()
+	defer r.rw.Unlock()
+	k, ok := r.keys[id]
+	if !ok {
+		e = ErrKeyNotFound
+		return
+	} else if k.retired {
+		e = ErrKeyRetired
+		return
+	} else if k.key == nil {
+		e = ErrKeyCannotSign
+		return
+	}
+	r.current = id
+	return
+}
+
+// 退役密鑰，使用它簽名的 token 將不再被接受，不能退役當前密鑰
+func (r *KeyRing) Retire(id string) (e error) {
+	r.rw.Lock()
+	defer r.rw.Unlock()
+	k, ok := r.keys[id]
+	if !ok {
+		e = ErrKeyNotFound
+		return
+	} else if id == r.current {
+		e = ErrKeyCurrent
+		return
+	}
+	k.retired = true
+	return
+}
+
+// 從密鑰環中刪除密鑰，不能刪除當前密鑰
+func (r *KeyRing) Remove(id string) (e error) {
+	r.rw.Lock()
+	defer r.rw.Unlock()
+	if id == r.current {
+		e = ErrKeyCurrent
+		return
+	}
+	delete(r.keys, id)
+	return
+}
+
+// 返回當前用於簽名的密鑰
+func (r *KeyRing) Current() (id string, key []byte, e error) {
+	id, k, e := r.CurrentKey()
+	if e != nil {
+		return
+	}
+	key = k.Bytes()
+	return
+}
+
+// 返回當前用於簽名的密鑰，Key 會緩存解析結果
+func (r *KeyRing) CurrentKey() (id string, key *Key, e error) {
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+	if r.current == `` {
+		e = ErrKeyNotFound
+		return
+	}
+	id = r.current
+	key = r.keys[id].key
+	return
+}
+
+// 返回用於驗證簽名的密鑰，如果密鑰不存在或已經退役返回錯誤
+func (r *KeyRing) Get(id string) (key []byte, e error) {
+	k, e := r.GetKey(id)
+	if e != nil {
+		return
+	}
+	key = k.Bytes()
+	return
+}
+
+// 返回用於驗證簽名的密鑰，Key 會緩存解析結果
+func (r *KeyRing) GetKey(id string) (key *Key, e error) {
+	r.rw.RLock()
+	defer r.rw.RUnlock()
+	k, ok := r.keys[id]
+	if !ok {
+		e = ErrKeyNotFound
+		return
+	} else if k.retired {
+		e = ErrKeyRetired
+		return
+	}
+	key = k.verify
+	return
+}
@@ -15,6 +15,15 @@ type SigningMethod interface {
 	Alg() string
 }
 
+// 可以直接使用 Key 緩存的解析結果簽名和驗證簽名，非對稱簽名算法實現了此接口
+type KeySigningMethod interface {
+	SigningMethod
+	// 如果簽名有效返回 nil
+	VerifyKey(key *Key, value []byte, signature string) error
+	// 爲 value 簽名
+	SignKey(key *Key, value []byte) (string, error)
+}
+
 // 註冊簽名 alg 的工廠方法，這通常在 init() 函數中完成
 func RegisterSigningMethod(alg string, f func() SigningMethod) {
 	signingMethodLock.Lock()
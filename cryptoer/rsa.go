@@ -0,0 +1,119 @@
+package cryptoer
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+)
+
+// Implements the RSA PKCS1 v1.5 family of signing methods
+// Expects a PEM or DER encoded private key for signing and public key for validation
+type SigningMethodRSA struct {
+	Name string
+	Hash crypto.Hash
+}
+
+// Specific instances for RS256 and company
+var (
+	SigningMethodRS256 *SigningMethodRSA
+	SigningMethodRS384 *SigningMethodRSA
+	SigningMethodRS512 *SigningMethodRSA
+)
+
+func init() {
+	// RS256
+	SigningMethodRS256 = &SigningMethodRSA{"RS256", crypto.SHA256}
+	RegisterSigningMethod(SigningMethodRS256.Alg(), func() SigningMethod {
+		return SigningMethodRS256
+	})
+
+	// RS384
+	SigningMethodRS384 = &SigningMethodRSA{"RS384", crypto.SHA384}
+	RegisterSigningMethod(SigningMethodRS384.Alg(), func() SigningMethod {
+		return SigningMethodRS384
+	})
+
+	// RS512
+	SigningMethodRS512 = &SigningMethodRSA{"RS512", crypto.SHA512}
+	RegisterSigningMethod(SigningMethodRS512.Alg(), func() SigningMethod {
+		return SigningMethodRS512
+	})
+}
+
+func (m *SigningMethodRSA) Alg() string {
+	return m.Name
+}
+
+// Verify the signature of RSXXX tokens.  Returns nil if the signature is valid.
+func (m *SigningMethodRSA) Verify(key, value []byte, signature string) error {
+	return m.VerifyKey(NewKey(key), value, signature)
+}
+
+// Verify the signature of RSXXX tokens with a parsed key.
+func (m *SigningMethodRSA) VerifyKey(key *Key, value []byte, signature string) error {
+	sig, err := DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	rsaKey, err := parseRSAPublicKey(key)
+	if err != nil {
+		return err
+	}
+	if !m.Hash.Available() {
+		return ErrHashUnavailable
+	}
+	hasher := m.Hash.New()
+	hasher.Write(value)
+
+	if rsa.VerifyPKCS1v15(rsaKey, m.Hash, hasher.Sum(nil), sig) != nil {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// Implements the Sign method from SigningMethod for this signing method.
+func (m *SigningMethodRSA) Sign(key, value []byte) (string, error) {
+	return m.SignKey(NewKey(key), value)
+}
+
+// Implements the SignKey method from KeySigningMethod for this signing method.
+func (m *SigningMethodRSA) SignKey(key *Key, value []byte) (string, error) {
+	rsaKey, err := parseRSAPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	if !m.Hash.Available() {
+		return "", ErrHashUnavailable
+	}
+	hasher := m.Hash.New()
+	hasher.Write(value)
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, rsaKey, m.Hash, hasher.Sum(nil))
+	if err != nil {
+		return "", err
+	}
+	return EncodeSegment(sig), nil
+}
+
+func parseRSAPrivateKey(key *Key) (*rsa.PrivateKey, error) {
+	signer, err := key.Signer()
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := signer.(*rsa.PrivateKey)
+	if !ok {
+		return nil, ErrInvalidKeyType
+	}
+	return rsaKey, nil
+}
+func parseRSAPublicKey(key *Key) (*rsa.PublicKey, error) {
+	pub, err := key.PublicKey()
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := pub.(*rsa.PublicKey)
+	if !ok {
+		return nil, ErrInvalidKeyType
+	}
+	return rsaKey, nil
+}
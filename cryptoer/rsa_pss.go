@@ -0,0 +1,107 @@
+package cryptoer
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+)
+
+// Implements the RSA-PSS family of signing methods
+// Expects a PEM or DER encoded private key for signing and public key for validation
+type SigningMethodRSAPSS struct {
+	*SigningMethodRSA
+	Options *rsa.PSSOptions
+}
+
+// Specific instances for PS256 and company
+var (
+	SigningMethodPS256 *SigningMethodRSAPSS
+	SigningMethodPS384 *SigningMethodRSAPSS
+	SigningMethodPS512 *SigningMethodRSAPSS
+)
+
+func init() {
+	// PS256
+	SigningMethodPS256 = &SigningMethodRSAPSS{
+		SigningMethodRSA: &SigningMethodRSA{"PS256", crypto.SHA256},
+		Options: &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+		},
+	}
+	RegisterSigningMethod(SigningMethodPS256.Alg(), func() SigningMethod {
+		return SigningMethodPS256
+	})
+
+	// PS384
+	SigningMethodPS384 = &SigningMethodRSAPSS{
+		SigningMethodRSA: &SigningMethodRSA{"PS384", crypto.SHA384},
+		Options: &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+		},
+	}
+	RegisterSigningMethod(SigningMethodPS384.Alg(), func() SigningMethod {
+		return SigningMethodPS384
+	})
+
+	// PS512
+	SigningMethodPS512 = &SigningMethodRSAPSS{
+		SigningMethodRSA: &SigningMethodRSA{"PS512", crypto.SHA512},
+		Options: &rsa.PSSOptions{
+			SaltLength: rsa.PSSSaltLengthEqualsHash,
+		},
+	}
+	RegisterSigningMethod(SigningMethodPS512.Alg(), func() SigningMethod {
+		return SigningMethodPS512
+	})
+}
+
+// Verify the signature of PSXXX tokens.  Returns nil if the signature is valid.
+func (m *SigningMethodRSAPSS) Verify(key, value []byte, signature string) error {
+	return m.VerifyKey(NewKey(key), value, signature)
+}
+
+// Verify the signature of PSXXX tokens with a parsed key.
+func (m *SigningMethodRSAPSS) VerifyKey(key *Key, value []byte, signature string) error {
+	sig, err := DecodeSegment(signature)
+	if err != nil {
+		return err
+	}
+	rsaKey, err := parseRSAPublicKey(key)
+	if err != nil {
+		return err
+	}
+	if !m.Hash.Available() {
+		return ErrHashUnavailable
+	}
+	hasher := m.Hash.New()
+	hasher.Write(value)
+
+	if rsa.VerifyPSS(rsaKey, m.Hash, hasher.Sum(nil), sig, m.Options) != nil {
+		return ErrSignatureInvalid
+	}
+	return nil
+}
+
+// Implements the Sign method from SigningMethod for this signing method.
+func (m *SigningMethodRSAPSS) Sign(key, value []byte) (string, error) {
+	return m.SignKey(NewKey(key), value)
+}
+
+// Implements the SignKey method from KeySigningMethod for this signing method.
+func (m *SigningMethodRSAPSS) SignKey(key *Key, value []byte) (string, error) {
+	rsaKey, err := parseRSAPrivateKey(key)
+	if err != nil {
+		return "", err
+	}
+	if !m.Hash.Available() {
+		return "", ErrHashUnavailable
+	}
+	hasher := m.Hash.New()
+	hasher.Write(value)
+
+	sig, err := rsa.SignPSS(rand.Reader, rsaKey, m.Hash, hasher.Sum(nil), m.Options)
+	if err != nil {
+		return "", err
+	}
+	return EncodeSegment(sig), nil
+}
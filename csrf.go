@@ -0,0 +1,50 @@
+package sessionstore
+
+import (
+	"strings"
+
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+)
+
+// CSRF token 簽名數據的前綴，避免與 token 簽名混用
+const csrfPrefix = `csrf.`
+
+// 返回與 access token 綁定的 CSRF token，使用 SigningMethod 對 access token 簽名得到，
+// 所以 Refresh 輪換 token 後 CSRF token 也隨之改變。使用密鑰環時格式爲 kid.sign
+func (m *Manager) CSRFToken(access string) (token string, e error) {
+	id, key, e := m.signingKey()
+	if e != nil {
+		return
+	}
+	token, e = m.sign(key, csrfPrefix+access)
+	if e != nil {
+		return
+	} else if id != `` {
+		token = id + `.` + token
+	}
+	return
+}
+
+// 驗證 CSRF token 是否與 access token 綁定，不匹配返回 cryptoer.ErrCSRFTokenNotMatched
+func (m *Manager) VerifyCSRF(access, token string) (e error) {
+	var id string
+	if m.opts.keys != nil {
+		i := strings.Index(token, `.`)
+		if i == -1 {
+			e = cryptoer.ErrCSRFTokenNotMatched
+			return
+		}
+		id = token[:i]
+		token = token[i+1:]
+	}
+	key, e := m.verifyingKey(id)
+	if e != nil {
+		e = cryptoer.ErrCSRFTokenNotMatched
+		return
+	}
+	e = m.verifySign(key, csrfPrefix+access, token)
+	if e != nil {
+		e = cryptoer.ErrCSRFTokenNotMatched
+	}
+	return
+}
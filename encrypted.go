@@ -0,0 +1,168 @@
+package sessionstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"time"
+
+	"github.com/powerpuffpenguin/sessionstore/store"
+)
+
+// 加密存儲的 Store 裝飾器，數據在寫入後端前使用 AEAD 加密，讀取時解密。
+// 存儲的數據格式爲 uvarint(version)+nonce+ciphertext，
+// 存儲 key 作爲附加數據參與認證，所以記錄不能在不同 key 之間互換
+type EncryptedStore struct {
+	store   Store
+	current uint64
+	aeads   map[uint64]cipher.AEAD
+}
+
+// 創建加密存儲，aeads 爲所有版本的密鑰，current 爲加密新數據使用的版本，
+// 保留舊版本的密鑰可以繼續讀取使用它加密的數據，以實現密鑰輪換
+func NewEncryptedStore(backend Store, current uint64, aeads map[uint64]cipher.AEAD) (s *EncryptedStore, e error) {
+	if _, ok := aeads[current]; !ok {
+		e = store.ErrUnknownKeyVersion
+		return
+	}
+	s = &EncryptedStore{
+		store:   backend,
+		current: current,
+		aeads:   aeads,
+	}
+	return
+}
+
+func (s *EncryptedStore) encrypt(key string, value []byte) (b []byte, e error) {
+	aead := s.aeads[s.current]
+	b = make([]byte, binary.MaxVarintLen64+aead.NonceSize(), binary.MaxVarintLen64+aead.NonceSize()+len(value)+aead.Overhead())
+	n := binary.PutUvarint(b, s.current)
+	b = b[:n+aead.NonceSize()]
+	nonce := b[n:]
+	_, e = rand.Read(nonce)
+	if e != nil {
+		return
+	}
+	b = aead.Seal(b, nonce, value, StringToBytes(key))
+	return
+}
+func (s *EncryptedStore) decrypt(key string, b []byte) (value []byte, e error) {
+	version, n := binary.Uvarint(b)
+	if n <= 0 {
+		e = store.ErrDecrypt
+		return
+	}
+	aead, ok := s.aeads[version]
+	if !ok {
+		e = store.ErrUnknownKeyVersion
+		return
+	}
+	b = b[n:]
+	if len(b) < aead.NonceSize() {
+		e = store.ErrDecrypt
+		return
+	}
+	value, e = aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], StringToBytes(key))
+	if e != nil {
+		e = store.ErrDecrypt
+	}
+	return
+}
+
+// 設置數據
+func (s *EncryptedStore) Put(ctx context.Context, key string, value []byte, deadline time.Time) (e error) {
+	b, e := s.encrypt(key, value)
+	if e != nil {
+		return
+	}
+	e = s.store.Put(ctx, key, b, deadline)
+	return
+}
+
+// 如果 key 的當前數據等於 old 則設置爲 value 並返回 true，如果 old 爲 nil 則只在 key 不存在時設置
+func (s *EncryptedStore) CompareAndSwap(ctx context.Context, key string, old, value []byte, deadline time.Time) (swapped bool, e error) {
+	// 密文每次都不同，所以先解密比較明文，再以讀取到的密文做 CompareAndSwap
+	current, e := getLatest(ctx, s.store, key)
+	if e != nil {
+		return
+	} else if old == nil {
+		if current != nil {
+			return
+		}
+		var b []byte
+		b, e = s.encrypt(key, value)
+		if e != nil {
+			return
+		}
+		swapped, e = s.store.CompareAndSwap(ctx, key, nil, b, deadline)
+		return
+	} else if current == nil {
+		return
+	}
+	plaintext, e := s.decrypt(key, current)
+	if e != nil {
+		return
+	} else if !bytes.Equal(plaintext, old) {
+		return
+	}
+	b, e := s.encrypt(key, value)
+	if e != nil {
+		return
+	}
+	swapped, e = s.store.CompareAndSwap(ctx, key, current, b, deadline)
+	return
+}
+
+// 返回數據
+func (s *EncryptedStore) Get(ctx context.Context, key string) (value []byte, e error) {
+	b, e := s.store.Get(ctx, key)
+	if e != nil || b == nil {
+		return
+	}
+	value, e = s.decrypt(key, b)
+	return
+}
+
+// 返回後端寫入節點上的最新數據
+func (s *EncryptedStore) GetLatest(ctx context.Context, key string) (value []byte, e error) {
+	b, e := getLatest(ctx, s.store, key)
+	if e != nil || b == nil {
+		return
+	}
+	value, e = s.decrypt(key, b)
+	return
+}
+
+// 刪除數據
+func (s *EncryptedStore) Del(ctx context.Context, key string) (e error) {
+	return s.store.Del(ctx, key)
+}
+
+// 刪除指定前綴的數據
+func (s *EncryptedStore) DelPrefix(ctx context.Context, prefix string) (e error) {
+	return s.store.DelPrefix(ctx, prefix)
+}
+
+// 遍歷指定前綴的數據，如果 f 返回 false 則停止遍歷
+func (s *EncryptedStore) Range(ctx context.Context, prefix string, f func(key string, value []byte) bool) (e error) {
+	var err error
+	e = s.store.Range(ctx, prefix, func(key string, b []byte) bool {
+		var value []byte
+		value, err = s.decrypt(key, b)
+		if err != nil {
+			return false
+		}
+		return f(key, value)
+	})
+	if e == nil {
+		e = err
+	}
+	return
+}
+
+// 關閉存儲設備 釋放相關資源
+func (s *EncryptedStore) Close() (e error) {
+	return s.store.Close()
+}
@@ -0,0 +1,11 @@
+package sessionstore
+
+import "errors"
+
+var (
+	ErrSessionType     = errors.New(`session type not matched`)
+	ErrUnknownCoder    = errors.New(`unknown coder`)
+	ErrCoderNotMatched = errors.New(`session coder not matched`)
+	ErrSchemaVersion   = errors.New(`session schema version cannot be upgraded`)
+	ErrSessionTooLarge = errors.New(`decompressed session too large`)
+)
@@ -0,0 +1,34 @@
+package sessionstore
+
+import (
+	"time"
+
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
+)
+
+// 返回 access 是否爲 寬限期內仍然有效的上一個 access token
+func (m *Manager) inGrace(raw *protoc_session.Raw, access string) bool {
+	return m.opts.grace > 0 &&
+		raw.Previous != nil &&
+		m.matchToken(raw.Previous.Access, access) &&
+		time.Now().Unix() <= raw.Previous.Deadline
+}
+
+// 返回 access 對應的 Token。寬限期內的上一個 access token 只返回它自己，
+// 有效期不會超過它原本的過期時間和寬限期結束時間，
+// 新的一組 token 只能由提供上一個 refresh token 的 Refresh 取得
+func (m *Manager) accessToken(raw *protoc_session.Raw, access string) *Token {
+	if access == `` || m.matchToken(raw.Token.Access, access) || !m.inGrace(raw, access) {
+		return m.rawToken(raw, access)
+	}
+	accessDeadline := raw.Previous.Deadline
+	if raw.Previous.AccessDeadline != 0 && raw.Previous.AccessDeadline < accessDeadline {
+		accessDeadline = raw.Previous.AccessDeadline
+	}
+	token := NewToken(access, ``,
+		accessDeadline, raw.Previous.Deadline,
+		raw.Token.Deadline,
+	)
+	token.Metadata = newMetadata(raw.Metadata, raw.Active)
+	return token
+}
@@ -0,0 +1,85 @@
+package grpc
+
+import (
+	"encoding/base64"
+	"errors"
+
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var ErrNoToken = errors.New(`token not found in metadata`)
+
+// 錯誤詳情 ErrorInfo 的 Domain
+const Domain = `sessionstore`
+
+// 錯誤詳情 ErrorInfo 的 Reason，客戶端可以據此區分驗證失敗的原因
+const (
+	ReasonNoToken       = `TOKEN_MISSING`
+	ReasonInvalidToken  = `TOKEN_INVALID`
+	ReasonNotExistToken = `TOKEN_NOT_EXISTS`
+	ReasonExpired       = `TOKEN_EXPIRED`
+	ReasonKeyNotFound   = `KEY_NOT_FOUND`
+	ReasonKeyRetired    = `KEY_RETIRED`
+	ReasonIdleTimeout   = `SESSION_IDLE_TIMEOUT`
+	ReasonBinding       = `SESSION_BINDING_NOT_MATCHED`
+	ReasonReauth        = `REAUTH_REQUIRED`
+)
+
+// 返回錯誤對應的 grpc 錯誤，驗證失敗爲 codes.Unauthenticated 並附帶 errdetails.ErrorInfo，
+// 其它錯誤爲 codes.Internal，爲了不洩露內部信息只返回通用的錯誤描述
+func toStatus(e error) error {
+	var (
+		reason  string
+		corrupt base64.CorruptInputError
+	)
+	switch {
+	case errors.Is(e, ErrNoToken):
+		reason = ReasonNoToken
+	case errors.Is(e, cryptoer.ErrInvalidToken),
+		errors.Is(e, cryptoer.ErrSignatureInvalid),
+		errors.Is(e, cryptoer.ErrInvalidKeyID),
+		errors.As(e, &corrupt):
+		reason = ReasonInvalidToken
+	case errors.Is(e, cryptoer.ErrKeyNotFound):
+		reason = ReasonKeyNotFound
+	case errors.Is(e, cryptoer.ErrKeyRetired):
+		reason = ReasonKeyRetired
+	case errors.Is(e, cryptoer.ErrNotExistsToken):
+		reason = ReasonNotExistToken
+	case errors.Is(e, cryptoer.ErrExpired):
+		reason = ReasonExpired
+	case errors.Is(e, cryptoer.ErrIdleTimeout):
+		reason = ReasonIdleTimeout
+	case errors.Is(e, cryptoer.ErrBindingNotMatched):
+		reason = ReasonBinding
+	case errors.Is(e, cryptoer.ErrReauthRequired):
+		reason = ReasonReauth
+	default:
+		return status.Error(codes.Internal, `internal error`)
+	}
+	s, err := status.New(codes.Unauthenticated, e.Error()).WithDetails(&errdetails.ErrorInfo{
+		Reason: reason,
+		Domain: Domain,
+	})
+	if err != nil {
+		return status.Error(codes.Unauthenticated, e.Error())
+	}
+	return s.Err()
+}
+
+// 返回 grpc 錯誤中 ErrorInfo 的 Reason，如果不是驗證失敗返回空字符串
+func Reason(e error) string {
+	s, ok := status.FromError(e)
+	if !ok || s.Code() != codes.Unauthenticated {
+		return ``
+	}
+	for _, detail := range s.Details() {
+		if info, ok := detail.(*errdetails.ErrorInfo); ok && info.Domain == Domain {
+			return info.Reason
+		}
+	}
+	return ``
+}
@@ -0,0 +1,113 @@
+package grpc
+
+import (
+	"context"
+	"strings"
+
+	"github.com/powerpuffpenguin/sessionstore"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// 驗證 metadata 中的 access token 並將 token 和 session 保存到 context 中，
+// 使用 sessionstore.FromContext 讀取
+type Interceptor struct {
+	m    *sessionstore.Manager
+	opts options
+}
+
+func New(m *sessionstore.Manager, opt ...Option) *Interceptor {
+	opts := defaultOptions
+	for _, o := range opt {
+		o.apply(&opts)
+	}
+	return &Interceptor{
+		m:    m,
+		opts: opts,
+	}
+}
+
+// 返回 access token，如果 metadata 中沒有 token 返回空字符串
+func (i *Interceptor) Extract(ctx context.Context) (access string) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return
+	}
+	scheme := i.opts.scheme
+	for _, value := range md.Get(i.opts.key) {
+		if scheme == `` {
+			access = value
+		} else if n := len(scheme); len(value) > n+1 && value[n] == ' ' && strings.EqualFold(value[:n], scheme) {
+			access = strings.TrimSpace(value[n+1:])
+		}
+		if access != `` {
+			return
+		}
+	}
+	return
+}
+
+// 返回 method 是否不需要驗證
+func (i *Interceptor) isAllowed(method string) bool {
+	if i.opts.allow == nil {
+		return false
+	} else if i.opts.allow[method] {
+		return true
+	}
+	j := strings.LastIndex(method, `/`)
+	return j != -1 && i.opts.allow[method[:j+1]+`*`]
+}
+
+// 驗證請求並返回保存了 session 的 context
+func (i *Interceptor) authenticate(ctx context.Context, method string) (context.Context, error) {
+	allowed := i.isAllowed(method)
+	access := i.Extract(ctx)
+	if access == `` {
+		if allowed {
+			return ctx, nil
+		}
+		return nil, toStatus(ErrNoToken)
+	}
+	token, session, e := i.m.Get(ctx, access, i.opts.metadata(ctx)...)
+	if e != nil {
+		if allowed {
+			return ctx, nil
+		}
+		return nil, toStatus(e)
+	}
+	return sessionstore.NewContext(ctx, token, session), nil
+}
+
+// 返回一元調用的服務器攔截器
+func (i *Interceptor) Unary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, e := i.authenticate(ctx, info.FullMethod)
+		if e != nil {
+			return nil, e
+		}
+		return handler(ctx, req)
+	}
+}
+
+// 返回流調用的服務器攔截器
+func (i *Interceptor) Stream() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, e := i.authenticate(ss.Context(), info.FullMethod)
+		if e != nil {
+			return e
+		}
+		return handler(srv, &serverStream{
+			ServerStream: ss,
+			ctx:          ctx,
+		})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
@@ -0,0 +1,31 @@
+package grpc
+
+import (
+	"context"
+	"net"
+
+	"github.com/powerpuffpenguin/sessionstore"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// 返回請求的客戶端信息，用於 Manager.Get 記錄元數據和檢查 session 綁定
+type MetadataFunc func(ctx context.Context) []sessionstore.MetadataOption
+
+// 返回請求的 客戶端 ip 和 User-Agent，用於 Manager.Put 和 Manager.Refresh 記錄 session 元數據，
+// 以及 Manager.Get 檢查 session 綁定
+func MetadataFromContext(ctx context.Context) (opt []sessionstore.MetadataOption) {
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ip, _, e := net.SplitHostPort(p.Addr.String())
+		if e != nil {
+			ip = p.Addr.String()
+		}
+		opt = append(opt, sessionstore.WithClientIP(ip))
+	}
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(`user-agent`); len(values) != 0 {
+			opt = append(opt, sessionstore.WithUserAgent(values[0]))
+		}
+	}
+	return
+}
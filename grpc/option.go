@@ -0,0 +1,66 @@
+package grpc
+
+var defaultOptions = options{
+	key:      `authorization`,
+	scheme:   `Bearer`,
+	metadata: MetadataFromContext,
+}
+
+type options struct {
+	// 保存 access token 的 metadata key
+	key string
+	// token 前的認證方案，如果爲空字符串則 metadata 值就是 token
+	scheme string
+	// 不需要驗證的方法
+	allow map[string]bool
+	// 返回請求的客戶端信息
+	metadata MetadataFunc
+}
+type Option interface {
+	apply(*options)
+}
+type funcOption struct {
+	f func(*options)
+}
+
+func (fdo *funcOption) apply(do *options) {
+	fdo.f(do)
+}
+func newFuncOption(f func(*options)) *funcOption {
+	return &funcOption{
+		f: f,
+	}
+}
+
+// 設置從哪個 metadata 讀取 access token，默認爲 "authorization" 和 "Bearer"
+func WithMetadata(key, scheme string) Option {
+	return newFuncOption(func(o *options) {
+		o.key = key
+		o.scheme = scheme
+	})
+}
+
+// 設置不需要驗證的公開方法，method 爲完整的方法名 /package.Service/Method，
+// 或者使用 /package.Service/* 公開整個服務。
+// 公開方法如果攜帶了有效的 token 依然會將 session 保存到 context
+func WithAllow(methods ...string) Option {
+	return newFuncOption(func(o *options) {
+		if o.allow == nil {
+			o.allow = make(map[string]bool, len(methods))
+		}
+		for _, method := range methods {
+			o.allow[method] = true
+		}
+	})
+}
+
+// 設置如何從請求中獲取客戶端信息，默認爲 MetadataFromContext。
+// 可以用於讀取代理轉發的客戶端 ip，或者使用 sessionstore.WithBindingValue 提供綁定值
+func WithClientMetadata(metadata MetadataFunc) Option {
+	return newFuncOption(func(o *options) {
+		if metadata == nil {
+			metadata = MetadataFromContext
+		}
+		o.metadata = metadata
+	})
+}
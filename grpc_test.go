@@ -0,0 +1,151 @@
+package sessionstore_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/powerpuffpenguin/sessionstore"
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+	sessiongrpc "github.com/powerpuffpenguin/sessionstore/grpc"
+	"github.com/powerpuffpenguin/sessionstore/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type testServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s testServerStream) Context() context.Context {
+	return s.ctx
+}
+func TestGRPCInterceptor(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+	)
+	token, e := m.Put(ctx, `1`, `grpc`, &Session{ID: `1`, Name: `grpc`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	interceptor := sessiongrpc.New(m,
+		sessiongrpc.WithAllow(`/test.Public/*`, `/test.Service/Login`),
+	)
+	unary := interceptor.Unary()
+	call := func(ctx context.Context, method string) (name string, e error) {
+		_, e = unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			if s, ok := sessionstore.SessionFromContext[Session](ctx); ok {
+				name = s.Name
+			}
+			return nil, nil
+		})
+		return
+	}
+	authorized := metadata.NewIncomingContext(ctx, metadata.Pairs(`authorization`, `Bearer `+token.Access))
+	name, e := call(authorized, `/test.Service/Get`)
+	if e != nil {
+		t.Fatal(e)
+	} else if name != `grpc` {
+		t.Fatal(`session not in context`)
+	}
+
+	_, e = call(ctx, `/test.Service/Get`)
+	if status.Code(e) != codes.Unauthenticated || sessiongrpc.Reason(e) != sessiongrpc.ReasonNoToken {
+		t.Fatal(`missing token not rejected`, e)
+	}
+	invalid := metadata.NewIncomingContext(ctx, metadata.Pairs(`authorization`, `Bearer `+token.Access+`x`))
+	_, e = call(invalid, `/test.Service/Get`)
+	if status.Code(e) != codes.Unauthenticated || sessiongrpc.Reason(e) != sessiongrpc.ReasonInvalidToken {
+		t.Fatal(`invalid token not rejected`, e)
+	}
+	for _, method := range []string{`/test.Public/Get`, `/test.Service/Login`} {
+		_, e = call(ctx, method)
+		if e != nil {
+			t.Fatal(`public method rejected`, method, e)
+		}
+	}
+
+	e = m.Delete(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	stream := interceptor.Stream()
+	e = stream(nil, testServerStream{ctx: authorized}, &grpc.StreamServerInfo{FullMethod: `/test.Service/Watch`}, func(srv interface{}, ss grpc.ServerStream) error {
+		return nil
+	})
+	if status.Code(e) != codes.Unauthenticated || sessiongrpc.Reason(e) != sessiongrpc.ReasonNotExistToken {
+		t.Fatal(`deleted token not rejected`, e)
+	}
+}
+func TestGRPCKeyRetired(t *testing.T) {
+	ctx := context.Background()
+	keys := cryptoer.NewKeyRing()
+	e := keys.Add(`k1`, []byte(`key 1`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithKeys(keys),
+		sessionstore.WithJWT(true),
+	)
+	token, e := m.Put(ctx, `1`, `grpc`, &Session{ID: `1`, Name: `grpc`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = keys.Add(`k2`, []byte(`key 2`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = keys.SetCurrent(`k2`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = keys.Retire(`k1`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	unary := sessiongrpc.New(m).Unary()
+	authorized := metadata.NewIncomingContext(ctx, metadata.Pairs(`authorization`, `Bearer `+token.Access))
+	_, e = unary(authorized, nil, &grpc.UnaryServerInfo{FullMethod: `/test.Service/Get`}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	if status.Code(e) != codes.Unauthenticated || sessiongrpc.Reason(e) != sessiongrpc.ReasonKeyRetired {
+		t.Fatal(`retired key not reported`, e)
+	}
+}
+func TestGRPCBindValue(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithBinding(sessionstore.BindValue(), nil),
+	)
+	token, e := m.Put(ctx, `1`, `grpc`, &Session{ID: `1`}, sessionstore.WithBindingValue(`cert-a`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	unary := sessiongrpc.New(m,
+		sessiongrpc.WithClientMetadata(func(ctx context.Context) []sessionstore.MetadataOption {
+			md, _ := metadata.FromIncomingContext(ctx)
+			return append(sessiongrpc.MetadataFromContext(ctx),
+				sessionstore.WithBindingValue(strings.Join(md.Get(`x-client-cert`), ``)),
+			)
+		}),
+	).Unary()
+	for _, cert := range []string{`cert-a`, `cert-b`} {
+		ctx := metadata.NewIncomingContext(ctx, metadata.Pairs(
+			`authorization`, `Bearer `+token.Access,
+			`x-client-cert`, cert,
+		))
+		_, e = unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: `/test.Service/Get`}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, nil
+		})
+		if (e == nil) != (cert == `cert-a`) {
+			t.Fatal(`binding value not checked`, cert, e)
+		}
+	}
+}
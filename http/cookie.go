@@ -0,0 +1,283 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/powerpuffpenguin/sessionstore"
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+)
+
+var defaultCookieOptions = cookieOptions{
+	access:      `access_token`,
+	refresh:     `refresh_token`,
+	path:        `/`,
+	refreshPath: `/refresh`,
+	secure:      true,
+	sameSite:    http.SameSiteLaxMode,
+	handler:     DefaultErrorHandler,
+	metadata:    MetadataFromRequest,
+}
+
+type cookieOptions struct {
+	// cookie 名稱
+	access  string
+	refresh string
+	// cookie 路徑
+	path        string
+	refreshPath string
+	domain      string
+	secure      bool
+	sameSite    http.SameSite
+	// 驗證失敗時的處理函數
+	handler ErrorHandler
+	// 如果爲 true 則沒有 cookie 的請求直接交給下一個處理器
+	optional bool
+	// 返回請求的客戶端信息
+	metadata MetadataFunc
+}
+type CookieOption interface {
+	apply(*cookieOptions)
+}
+type funcCookieOption struct {
+	f func(*cookieOptions)
+}
+
+func (fdo *funcCookieOption) apply(do *cookieOptions) {
+	fdo.f(do)
+}
+func newFuncCookieOption(f func(*cookieOptions)) *funcCookieOption {
+	return &funcCookieOption{
+		f: f,
+	}
+}
+
+// 設置 access 和 refresh cookie 的名稱，默認爲 "access_token" 和 "refresh_token"
+func WithCookieName(access, refresh string) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.access = access
+		o.refresh = refresh
+	})
+}
+
+// 設置 access 和 refresh cookie 的路徑，默認爲 "/" 和 "/refresh"。
+// 將 refresh 限制到刷新接口的路徑可以減少 refresh token 的暴露，
+// 此時只有訪問該路徑的請求才能自動刷新，客戶端需要在 access 過期後請求該路徑。
+// refresh cookie 中也保存了 access token，所以 access 路徑不應該比 refresh 路徑更窄
+func WithCookiePath(access, refresh string) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.path = access
+		o.refreshPath = refresh
+	})
+}
+
+// 設置 cookie 的域名
+func WithCookieDomain(domain string) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.domain = domain
+	})
+}
+
+// 設置 cookie 是否只通過 https 發送，默認爲 true
+func WithCookieSecure(secure bool) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.secure = secure
+	})
+}
+
+// 設置 cookie 的 SameSite，默認爲 http.SameSiteLaxMode
+func WithCookieSameSite(sameSite http.SameSite) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.sameSite = sameSite
+	})
+}
+
+// 設置驗證失敗時的處理函數，默認爲 DefaultErrorHandler
+func WithCookieErrorHandler(handler ErrorHandler) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		if handler == nil {
+			handler = DefaultErrorHandler
+		}
+		o.handler = handler
+	})
+}
+
+// 如果爲 true 則沒有攜帶 cookie 的請求不會被拒絕，而是不帶 session 交給下一個處理器
+func WithCookieOptional(optional bool) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		o.optional = optional
+	})
+}
+
+// 設置如何從請求中獲取客戶端信息，默認爲 MetadataFromRequest
+func WithCookieMetadata(metadata MetadataFunc) CookieOption {
+	return newFuncCookieOption(func(o *cookieOptions) {
+		if metadata == nil {
+			metadata = MetadataFromRequest
+		}
+		o.metadata = metadata
+	})
+}
+
+// refresh cookie 中 access 和 refresh token 的分隔符，不會出現在 token 中
+const cookieSeparator = `~`
+
+// 使用 HttpOnly cookie 保存 token。
+// access cookie 在 AccessDeadline 過期，refresh cookie 在 RefreshDeadline 過期，
+// 因爲 Manager.Refresh 需要同時提供兩個 token，所以 refresh cookie 中同時保存了 access 和 refresh token。
+// 這意味着 access token 也會被發送到 refresh cookie 的路徑，在 access cookie 過期後仍然保留，
+// 但它只能在有效期內使用，過期後只能和 refresh token 一起用於刷新。
+//
+// 瀏覽器可能攜帶同一組過期的 cookie 並行發送多個請求，只有第一個請求能夠輪換 token，
+// 所以應該爲 Manager 設置 sessionstore.WithRefreshGrace，使其它請求在寬限期內得到同一組新 token。
+// 沒有寬限期時其它請求返回驗證失敗，但不會刪除 cookie，以免覆蓋第一個請求寫入的新 cookie
+type Cookies struct {
+	m    *sessionstore.Manager
+	opts cookieOptions
+}
+
+func NewCookies(m *sessionstore.Manager, opt ...CookieOption) *Cookies {
+	opts := defaultCookieOptions
+	for _, o := range opt {
+		o.apply(&opts)
+	}
+	return &Cookies{
+		m:    m,
+		opts: opts,
+	}
+}
+func (c *Cookies) cookie(name, value, path string, expires time.Time) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     path,
+		Domain:   c.opts.domain,
+		Expires:  expires,
+		Secure:   c.opts.secure,
+		HttpOnly: true,
+		SameSite: c.opts.sameSite,
+	}
+	if value == `` {
+		cookie.MaxAge = -1
+	}
+	return cookie
+}
+
+// 將 token 寫入 access 和 refresh cookie
+func (c *Cookies) Set(w http.ResponseWriter, token *sessionstore.Token) {
+	http.SetCookie(w, c.cookie(c.opts.access, token.Access, c.opts.path,
+		time.Unix(token.AccessDeadline, 0),
+	))
+	http.SetCookie(w, c.cookie(c.opts.refresh, token.Access+cookieSeparator+token.Refresh, c.opts.refreshPath,
+		time.Unix(token.RefreshDeadline, 0),
+	))
+}
+
+// 刪除 access 和 refresh cookie
+func (c *Cookies) Clear(w http.ResponseWriter) {
+	http.SetCookie(w, c.cookie(c.opts.access, ``, c.opts.path, time.Unix(0, 0)))
+	http.SetCookie(w, c.cookie(c.opts.refresh, ``, c.opts.refreshPath, time.Unix(0, 0)))
+}
+
+// 返回 access cookie 中的 token，如果沒有返回空字符串
+func (c *Cookies) Access(r *http.Request) (access string) {
+	cookie, e := r.Cookie(c.opts.access)
+	if e == nil {
+		access = cookie.Value
+	}
+	return
+}
+
+// 返回 refresh cookie 中的 token，如果沒有返回空字符串
+func (c *Cookies) Refresh(r *http.Request) (access, refresh string) {
+	cookie, e := r.Cookie(c.opts.refresh)
+	if e != nil {
+		return
+	}
+	i := strings.Index(cookie.Value, cookieSeparator)
+	if i == -1 {
+		return
+	}
+	access = cookie.Value[:i]
+	refresh = cookie.Value[i+len(cookieSeparator):]
+	return
+}
+
+// 使用 cookie 中的 token 返回 session，
+// 如果 access 已經過期(或者瀏覽器已經刪除了 access cookie)則使用 refresh cookie 刷新 token 並重新寫入 cookie，
+// 刷新失敗時刪除 cookie
+func (c *Cookies) Get(w http.ResponseWriter, r *http.Request) (token *sessionstore.Token, session interface{}, e error) {
+	token, session, _, e = c.get(w, r)
+	return
+}
+
+// 返回 session，如果 token 被刷新 refreshed 爲刷新前的 access token
+func (c *Cookies) get(w http.ResponseWriter, r *http.Request) (token *sessionstore.Token, session interface{}, refreshed string, e error) {
+	access := c.Access(r)
+	if access != `` {
+		token, session, e = c.m.Get(r.Context(), access, c.opts.metadata(r)...)
+		if !errors.Is(e, cryptoer.ErrExpired) {
+			return
+		}
+	}
+	oldAccess, refresh := c.Refresh(r)
+	if refresh == `` {
+		if e == nil {
+			e = ErrNoToken
+		}
+		return
+	}
+	token, session, e = c.m.Refresh(r.Context(), oldAccess, refresh, c.opts.metadata(r)...)
+	if e != nil {
+		if isRevoked(e) {
+			c.Clear(w)
+		}
+		return
+	}
+	c.Set(w, token)
+	refreshed = oldAccess
+	return
+}
+
+// 返回 Refresh 的錯誤是否表示 cookie 中的 token 已經不可能再使用。
+// ErrNotExistsToken 和 ErrRefreshTokenNotMatched 可能是並行請求剛輪換了 token，
+// 存儲錯誤和 ErrConflict 是暫時的，這些情況下不刪除 cookie
+func isRevoked(e error) bool {
+	return errors.Is(e, cryptoer.ErrInvalidToken) ||
+		errors.Is(e, cryptoer.ErrSignatureInvalid) ||
+		errors.Is(e, cryptoer.ErrExpired) ||
+		errors.Is(e, cryptoer.ErrIdleTimeout) ||
+		errors.Is(e, cryptoer.ErrCannotRefresh) ||
+		errors.Is(e, cryptoer.ErrRefreshTokenReused) ||
+		errors.Is(e, cryptoer.ErrKeyNotFound) ||
+		errors.Is(e, cryptoer.ErrKeyRetired) ||
+		errors.Is(e, cryptoer.ErrBindingNotMatched) ||
+		errors.Is(e, cryptoer.ErrReauthRequired)
+}
+
+// 包裝 next，只有驗證通過的請求才會交給 next 處理，token 和 session 被保存到請求的 context 中
+func (c *Cookies) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, session, refreshed, e := c.get(w, r)
+		if e != nil {
+			if errors.Is(e, ErrNoToken) && c.opts.optional {
+				next.ServeHTTP(w, r)
+			} else {
+				c.opts.handler(w, r, &Error{Err: e})
+			}
+			return
+		}
+		ctx := NewContext(r.Context(), token, session)
+		if refreshed != `` {
+			ctx = withRefreshed(ctx, refreshed)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// 包裝 next，只有驗證通過的請求才會交給 next 處理
+func (c *Cookies) HandlerFunc(next http.HandlerFunc) http.Handler {
+	return c.Handler(next)
+}
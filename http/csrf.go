@@ -0,0 +1,168 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/powerpuffpenguin/sessionstore"
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+)
+
+var defaultCSRFOptions = csrfOptions{
+	header:  `X-CSRF-Token`,
+	field:   `csrf_token`,
+	handler: DefaultErrorHandler,
+}
+
+type csrfOptions struct {
+	// 提交 CSRF token 的 header
+	header string
+	// 提交 CSRF token 的表單字段
+	field string
+	// 驗證失敗時的處理函數
+	handler ErrorHandler
+}
+type CSRFOption interface {
+	apply(*csrfOptions)
+}
+type funcCSRFOption struct {
+	f func(*csrfOptions)
+}
+
+func (fdo *funcCSRFOption) apply(do *csrfOptions) {
+	fdo.f(do)
+}
+func newFuncCSRFOption(f func(*csrfOptions)) *funcCSRFOption {
+	return &funcCSRFOption{
+		f: f,
+	}
+}
+
+// 設置提交 CSRF token 的 header，響應中也使用此 header 返回當前的 CSRF token，默認爲 "X-CSRF-Token"
+func WithCSRFHeader(header string) CSRFOption {
+	return newFuncCSRFOption(func(o *csrfOptions) {
+		o.header = header
+	})
+}
+
+// 設置提交 CSRF token 的表單字段，默認爲 "csrf_token"，如果爲空字符串則不從表單讀取
+func WithCSRFField(field string) CSRFOption {
+	return newFuncCSRFOption(func(o *csrfOptions) {
+		o.field = field
+	})
+}
+
+// 設置驗證失敗時的處理函數，默認爲 DefaultErrorHandler
+func WithCSRFErrorHandler(handler ErrorHandler) CSRFOption {
+	return newFuncCSRFOption(func(o *csrfOptions) {
+		if handler == nil {
+			handler = DefaultErrorHandler
+		}
+		o.handler = handler
+	})
+}
+
+type csrfKey struct{}
+
+// 返回 CSRF 中間件保存在 context 中的 CSRF token，用於寫入模板
+func CSRFToken(ctx context.Context) (token string, ok bool) {
+	token, ok = ctx.Value(csrfKey{}).(string)
+	return
+}
+
+type refreshedKey struct{}
+
+// 記錄本次請求中被刷新前的 access token
+func withRefreshed(ctx context.Context, access string) context.Context {
+	return context.WithValue(ctx, refreshedKey{}, access)
+}
+
+// 驗證與 session 綁定的 CSRF token，必須放在 Middleware 或 Cookies 之後使用
+type CSRF struct {
+	m    *sessionstore.Manager
+	opts csrfOptions
+}
+
+func NewCSRF(m *sessionstore.Manager, opt ...CSRFOption) *CSRF {
+	opts := defaultCSRFOptions
+	for _, o := range opt {
+		o.apply(&opts)
+	}
+	return &CSRF{
+		m:    m,
+		opts: opts,
+	}
+}
+
+// 返回請求是否可能修改數據
+func isUnsafe(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return false
+	}
+	return true
+}
+
+// 返回請求提交的 CSRF token
+func (c *CSRF) Extract(r *http.Request) (token string) {
+	if c.opts.header != `` {
+		token = r.Header.Get(c.opts.header)
+	}
+	if token == `` && c.opts.field != `` {
+		token = r.PostFormValue(c.opts.field)
+	}
+	return
+}
+
+// 驗證請求提交的 CSRF token，如果 access token 在本次請求中被刷新則也接受刷新前的 CSRF token
+func (c *CSRF) verify(r *http.Request, access string) (e error) {
+	token := c.Extract(r)
+	if token == `` {
+		e = cryptoer.ErrCSRFTokenNotMatched
+		return
+	}
+	e = c.m.VerifyCSRF(access, token)
+	if e != nil {
+		if old, ok := r.Context().Value(refreshedKey{}).(string); ok {
+			e = c.m.VerifyCSRF(old, token)
+		}
+	}
+	return
+}
+
+// 包裝 next，不安全的請求方法(POST PUT PATCH DELETE 等)必須提交正確的 CSRF token，
+// 當前的 CSRF token 會被保存到 context 並寫入響應 header
+func (c *CSRF) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		token, _, ok := FromContext(r.Context())
+		if !ok {
+			if isUnsafe(r.Method) {
+				c.opts.handler(w, r, &Error{Err: cryptoer.ErrCSRFTokenNotMatched})
+			} else {
+				next.ServeHTTP(w, r)
+			}
+			return
+		}
+		if isUnsafe(r.Method) {
+			e := c.verify(r, token.Access)
+			if e != nil {
+				c.opts.handler(w, r, &Error{Err: e})
+				return
+			}
+		}
+		csrf, e := c.m.CSRFToken(token.Access)
+		if e != nil {
+			c.opts.handler(w, r, &Error{Err: e})
+			return
+		}
+		if c.opts.header != `` {
+			w.Header().Set(c.opts.header, csrf)
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), csrfKey{}, csrf)))
+	})
+}
+
+// 包裝 next，不安全的請求方法必須提交正確的 CSRF token
+func (c *CSRF) HandlerFunc(next http.HandlerFunc) http.Handler {
+	return c.Handler(next)
+}
@@ -0,0 +1,97 @@
+package http
+
+import (
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+)
+
+var ErrNoToken = errors.New(`token not found in request`)
+
+// 驗證失敗時的處理函數，e 爲 *Error
+type ErrorHandler func(w http.ResponseWriter, r *http.Request, e error)
+
+// 中間件驗證失敗的錯誤
+type Error struct {
+	// WWW-Authenticate 中的 realm
+	Realm string
+	Err   error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// 返回錯誤對應的 http 狀態碼
+func (e *Error) StatusCode() int {
+	return StatusCode(e.Err)
+}
+
+// 返回錯誤對應的 WWW-Authenticate，如果不需要此 header 返回空字符串
+func (e *Error) Authenticate() string {
+	if StatusCode(e.Err) != http.StatusUnauthorized {
+		return ``
+	}
+	value := `Bearer`
+	sep := ` `
+	if e.Realm != `` {
+		value += sep + `realm=` + strconv.Quote(e.Realm)
+		sep = `, `
+	}
+	if errors.Is(e.Err, ErrNoToken) {
+		// RFC 6750 沒有提供認證信息時不應該包含錯誤碼
+		return value
+	}
+	return value + sep + `error="invalid_token", error_description=` + strconv.Quote(e.Err.Error())
+}
+
+// 返回 cryptoer 錯誤對應的 http 狀態碼
+func StatusCode(e error) int {
+	var corrupt base64.CorruptInputError
+	switch {
+	case errors.Is(e, ErrNoToken),
+		errors.Is(e, cryptoer.ErrInvalidToken),
+		errors.Is(e, cryptoer.ErrSignatureInvalid),
+		errors.Is(e, cryptoer.ErrInvalidKeyID),
+		errors.As(e, &corrupt),
+		errors.Is(e, cryptoer.ErrNotExistsToken),
+		errors.Is(e, cryptoer.ErrExpired),
+		errors.Is(e, cryptoer.ErrIdleTimeout),
+		errors.Is(e, cryptoer.ErrRefreshTokenNotMatched),
+		errors.Is(e, cryptoer.ErrRefreshTokenReused),
+		errors.Is(e, cryptoer.ErrCannotRefresh),
+		errors.Is(e, cryptoer.ErrBindingNotMatched),
+		errors.Is(e, cryptoer.ErrReauthRequired),
+		errors.Is(e, cryptoer.ErrKeyNotFound),
+		errors.Is(e, cryptoer.ErrKeyRetired):
+		return http.StatusUnauthorized
+	case errors.Is(e, cryptoer.ErrCSRFTokenNotMatched):
+		return http.StatusForbidden
+	case errors.Is(e, cryptoer.ErrTooManySessions):
+		return http.StatusTooManyRequests
+	case errors.Is(e, cryptoer.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(e, cryptoer.ErrNotSupported):
+		return http.StatusNotImplemented
+	}
+	return http.StatusInternalServerError
+}
+
+// 默認的錯誤處理函數，設置 WWW-Authenticate 並返回錯誤對應的狀態碼
+func DefaultErrorHandler(w http.ResponseWriter, r *http.Request, e error) {
+	var err *Error
+	if !errors.As(e, &err) {
+		err = &Error{Err: e}
+	}
+	code := err.StatusCode()
+	if value := err.Authenticate(); value != `` {
+		w.Header().Set(`WWW-Authenticate`, value)
+	}
+	http.Error(w, http.StatusText(code), code)
+}
@@ -0,0 +1,25 @@
+package http
+
+import (
+	"net"
+	"net/http"
+
+	"github.com/powerpuffpenguin/sessionstore"
+)
+
+// 返回請求的客戶端信息，用於 Manager.Get 和 Manager.Refresh 記錄元數據和檢查 session 綁定
+type MetadataFunc func(r *http.Request) []sessionstore.MetadataOption
+
+// 返回請求的 客戶端 ip 和 User-Agent，用於 Manager.Put 和 Manager.Refresh 記錄 session 元數據，
+// 以及 Manager.Get 檢查 session 綁定。
+// ip 取自 RemoteAddr，如果服務位於反向代理之後應該使用 WithMetadata 自行從代理 header 中讀取
+func MetadataFromRequest(r *http.Request) []sessionstore.MetadataOption {
+	ip, _, e := net.SplitHostPort(r.RemoteAddr)
+	if e != nil {
+		ip = r.RemoteAddr
+	}
+	return []sessionstore.MetadataOption{
+		sessionstore.WithClientIP(ip),
+		sessionstore.WithUserAgent(r.UserAgent()),
+	}
+}
@@ -0,0 +1,77 @@
+package http
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/powerpuffpenguin/sessionstore"
+)
+
+// 返回保存了 token 和 session 的 context
+func NewContext(ctx context.Context, token *sessionstore.Token, session interface{}) context.Context {
+	return sessionstore.NewContext(ctx, token, session)
+}
+
+// 返回中間件保存在 context 中的 token 和 session
+func FromContext(ctx context.Context) (token *sessionstore.Token, session interface{}, ok bool) {
+	return sessionstore.FromContext(ctx)
+}
+
+// 返回中間件保存在 context 中的 *T 類型 session
+func Session[T any](ctx context.Context) (session *T, ok bool) {
+	return sessionstore.SessionFromContext[T](ctx)
+}
+
+// 驗證請求中的 access token 並將 token 和 session 保存到請求的 context 中
+type Middleware struct {
+	m    *sessionstore.Manager
+	opts options
+}
+
+func New(m *sessionstore.Manager, opt ...Option) *Middleware {
+	opts := defaultOptions
+	for _, o := range opt {
+		o.apply(&opts)
+	}
+	return &Middleware{
+		m:    m,
+		opts: opts,
+	}
+}
+
+// 返回 access token，如果請求中沒有 token 返回空字符串
+func (mw *Middleware) Extract(r *http.Request) (access string) {
+	for _, extractor := range mw.opts.extractors {
+		access = extractor(r)
+		if access != `` {
+			return
+		}
+	}
+	return
+}
+
+// 包裝 next，只有驗證通過的請求才會交給 next 處理
+func (mw *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		access := mw.Extract(r)
+		if access == `` {
+			if mw.opts.optional {
+				next.ServeHTTP(w, r)
+			} else {
+				mw.opts.handler(w, r, &Error{Realm: mw.opts.realm, Err: ErrNoToken})
+			}
+			return
+		}
+		token, session, e := mw.m.Get(r.Context(), access, mw.opts.metadata(r)...)
+		if e != nil {
+			mw.opts.handler(w, r, &Error{Realm: mw.opts.realm, Err: e})
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(NewContext(r.Context(), token, session)))
+	})
+}
+
+// 包裝 next，只有驗證通過的請求才會交給 next 處理
+func (mw *Middleware) HandlerFunc(next http.HandlerFunc) http.Handler {
+	return mw.Handler(next)
+}
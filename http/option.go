@@ -0,0 +1,119 @@
+package http
+
+import (
+	"net/http"
+	"strings"
+)
+
+var defaultOptions = options{
+	extractors: []Extractor{FromHeader(`Authorization`, `Bearer`)},
+	handler:    DefaultErrorHandler,
+	metadata:   MetadataFromRequest,
+}
+
+type options struct {
+	// 依次嘗試從請求中提取 access token
+	extractors []Extractor
+	// 驗證失敗時的處理函數
+	handler ErrorHandler
+	// WWW-Authenticate 中的 realm
+	realm string
+	// 如果爲 true 則沒有 token 的請求直接交給下一個處理器
+	optional bool
+	// 返回請求的客戶端信息
+	metadata MetadataFunc
+}
+type Option interface {
+	apply(*options)
+}
+type funcOption struct {
+	f func(*options)
+}
+
+func (fdo *funcOption) apply(do *options) {
+	fdo.f(do)
+}
+func newFuncOption(f func(*options)) *funcOption {
+	return &funcOption{
+		f: f,
+	}
+}
+
+// 設置提取 access token 的方式，按順序使用第一個找到的 token，
+// 默認爲 FromHeader("Authorization", "Bearer")
+func WithExtractor(extractors ...Extractor) Option {
+	return newFuncOption(func(o *options) {
+		o.extractors = extractors
+	})
+}
+
+// 設置驗證失敗時的處理函數，默認爲 DefaultErrorHandler
+func WithErrorHandler(handler ErrorHandler) Option {
+	return newFuncOption(func(o *options) {
+		if handler == nil {
+			handler = DefaultErrorHandler
+		}
+		o.handler = handler
+	})
+}
+
+// 設置 WWW-Authenticate 中的 realm
+func WithRealm(realm string) Option {
+	return newFuncOption(func(o *options) {
+		o.realm = realm
+	})
+}
+
+// 如果爲 true 則沒有攜帶 token 的請求不會被拒絕，而是不帶 session 交給下一個處理器
+func WithOptional(optional bool) Option {
+	return newFuncOption(func(o *options) {
+		o.optional = optional
+	})
+}
+
+// 設置如何從請求中獲取客戶端信息，默認爲 MetadataFromRequest。
+// 可以用於讀取反向代理 header 中的客戶端 ip，或者使用 sessionstore.WithBindingValue 提供綁定值
+func WithMetadata(metadata MetadataFunc) Option {
+	return newFuncOption(func(o *options) {
+		if metadata == nil {
+			metadata = MetadataFromRequest
+		}
+		o.metadata = metadata
+	})
+}
+
+// 從請求中提取 access token，如果沒有找到返回空字符串
+type Extractor func(r *http.Request) (access string)
+
+// 從 header 中提取 token，如果 scheme 不爲空字符串則 header 格式必須爲 "scheme token"(scheme 不區分大小寫)
+func FromHeader(name, scheme string) Extractor {
+	return func(r *http.Request) (access string) {
+		value := r.Header.Get(name)
+		if scheme == `` {
+			return value
+		}
+		n := len(scheme)
+		if len(value) > n+1 && value[n] == ' ' && strings.EqualFold(value[:n], scheme) {
+			access = strings.TrimSpace(value[n+1:])
+		}
+		return
+	}
+}
+
+// 從 cookie 中提取 token
+func FromCookie(name string) Extractor {
+	return func(r *http.Request) (access string) {
+		c, e := r.Cookie(name)
+		if e == nil {
+			access = c.Value
+		}
+		return
+	}
+}
+
+// 從 url 查詢參數中提取 token
+func FromQuery(name string) Extractor {
+	return func(r *http.Request) (access string) {
+		return r.URL.Query().Get(name)
+	}
+}
@@ -0,0 +1,315 @@
+package sessionstore_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/powerpuffpenguin/sessionstore"
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+	sessionhttp "github.com/powerpuffpenguin/sessionstore/http"
+	"github.com/powerpuffpenguin/sessionstore/store"
+)
+
+func TestHTTPMiddleware(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithAccess(time.Second),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: `http`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	mw := sessionhttp.New(m,
+		sessionhttp.WithExtractor(
+			sessionhttp.FromHeader(`Authorization`, `Bearer`),
+			sessionhttp.FromCookie(`access`),
+			sessionhttp.FromQuery(`access_token`),
+		),
+		sessionhttp.WithRealm(`test`),
+	)
+	handler := mw.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, ok := sessionhttp.Session[Session](r.Context())
+		if !ok {
+			t.Fatal(`session not in context`)
+		}
+		w.Write([]byte(s.Name))
+	})
+	serve := func(f func(r *http.Request)) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, `/`, nil)
+		f(r)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	for _, f := range []func(r *http.Request){
+		func(r *http.Request) {
+			r.Header.Set(`Authorization`, `bearer `+token.Access)
+		},
+		func(r *http.Request) {
+			r.AddCookie(&http.Cookie{Name: `access`, Value: token.Access})
+		},
+		func(r *http.Request) {
+			r.URL.RawQuery = `access_token=` + token.Access
+		},
+	} {
+		w := serve(f)
+		if w.Code != http.StatusOK || w.Body.String() != `http` {
+			t.Fatal(`request not authenticated`, w.Code)
+		}
+	}
+
+	w := serve(func(r *http.Request) {})
+	if w.Code != http.StatusUnauthorized || w.Header().Get(`WWW-Authenticate`) != `Bearer realm="test"` {
+		t.Fatal(`missing token not rejected`, w.Code, w.Header().Get(`WWW-Authenticate`))
+	}
+
+	for _, malformed := range []string{`a.b`, `a.b.c`} {
+		w = serve(func(r *http.Request) {
+			r.Header.Set(`Authorization`, `Bearer `+malformed)
+		})
+		if w.Code != http.StatusUnauthorized ||
+			!strings.Contains(w.Header().Get(`WWW-Authenticate`), `error="invalid_token"`) {
+			t.Fatal(`malformed token not rejected`, malformed, w.Code, w.Header().Get(`WWW-Authenticate`))
+		}
+	}
+
+	time.Sleep(time.Second * 2)
+	w = serve(func(r *http.Request) {
+		r.Header.Set(`Authorization`, `Bearer `+token.Access)
+	})
+	if w.Code != http.StatusUnauthorized ||
+		!strings.Contains(w.Header().Get(`WWW-Authenticate`), `error="invalid_token"`) {
+		t.Fatal(`expired token not rejected`, w.Code, w.Header().Get(`WWW-Authenticate`))
+	}
+
+	var handled error
+	mw = sessionhttp.New(m,
+		sessionhttp.WithErrorHandler(func(w http.ResponseWriter, r *http.Request, e error) {
+			handled = e
+			w.WriteHeader(http.StatusTeapot)
+		}),
+	)
+	handler = mw.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	w = serve(func(r *http.Request) {})
+	if w.Code != http.StatusTeapot || handled == nil {
+		t.Fatal(`custom error handler not called`)
+	}
+}
+
+func TestHTTPCookies(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithAccess(time.Second),
+	)
+	cookies := sessionhttp.NewCookies(m,
+		sessionhttp.WithCookiePath(`/`, `/auth`),
+		sessionhttp.WithCookieSameSite(http.SameSiteStrictMode),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: `cookie`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	w := httptest.NewRecorder()
+	cookies.Set(w, token)
+	set := w.Result().Cookies()
+	if len(set) != 2 {
+		t.Fatal(`cookies not set`)
+	}
+	access, refresh := set[0], set[1]
+	if !access.HttpOnly || !access.Secure || access.SameSite != http.SameSiteStrictMode ||
+		access.Expires.Unix() != token.AccessDeadline || access.Path != `/` {
+		t.Fatal(`access cookie not matched`, access)
+	} else if refresh.Expires.Unix() != token.RefreshDeadline || refresh.Path != `/auth` {
+		t.Fatal(`refresh cookie not matched`, refresh)
+	}
+
+	handler := cookies.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s, ok := sessionhttp.Session[Session](r.Context())
+		if !ok {
+			t.Fatal(`session not in context`)
+		}
+		w.Write([]byte(s.Name))
+	})
+	serve := func(cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, `/auth`, nil)
+		for _, cookie := range cookies {
+			r.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	w = serve(access)
+	if w.Code != http.StatusOK || len(w.Result().Cookies()) != 0 {
+		t.Fatal(`access cookie not accepted`, w.Code)
+	}
+
+	time.Sleep(time.Second * 2)
+	w = serve(access)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatal(`expired access accepted`, w.Code)
+	}
+	w = serve(access, refresh)
+	if w.Code != http.StatusOK || w.Body.String() != `cookie` {
+		t.Fatal(`not refreshed`, w.Code)
+	}
+	set = w.Result().Cookies()
+	if len(set) != 2 || set[0].Value == access.Value {
+		t.Fatal(`cookies not reissued`)
+	}
+
+	// 瀏覽器刪除了過期的 access cookie
+	time.Sleep(time.Second * 2)
+	w = serve(set[1])
+	if w.Code != http.StatusOK || len(w.Result().Cookies()) != 2 {
+		t.Fatal(`not refreshed without access cookie`, w.Code)
+	}
+	// 舊的 token 可能剛被並行請求輪換，拒絕但不刪除 cookie
+	w = serve(refresh)
+	if w.Code != http.StatusUnauthorized || len(w.Result().Cookies()) != 0 {
+		t.Fatal(`rotated refresh not rejected or cookies cleared`, w.Code)
+	}
+	// 無法再刷新的 token 刪除 cookie
+	w = serve(&http.Cookie{Name: refresh.Name, Value: `a.b~c.d`})
+	if w.Code != http.StatusUnauthorized || len(w.Result().Cookies()) != 2 || w.Result().Cookies()[0].MaxAge >= 0 {
+		t.Fatal(`invalid refresh not cleared`, w.Code)
+	}
+
+	// 寬限期內並行請求得到同一組新 token
+	m = sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithAccess(time.Second),
+		sessionstore.WithRefreshGrace(time.Minute),
+	)
+	cookies = sessionhttp.NewCookies(m)
+	handler = cookies.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	token, e = m.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: `cookie`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	w = httptest.NewRecorder()
+	cookies.Set(w, token)
+	set = w.Result().Cookies()
+	time.Sleep(time.Second * 2)
+	first, second := serve(set...), serve(set...)
+	if first.Code != http.StatusOK || second.Code != http.StatusOK {
+		t.Fatal(`parallel refresh rejected`, first.Code, second.Code)
+	} else if first.Result().Cookies()[0].Value != second.Result().Cookies()[0].Value {
+		t.Fatal(`parallel refresh returned another token`)
+	}
+}
+
+func TestHTTPCSRF(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithAccess(time.Second),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: `csrf`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	csrf, e := m.CSRFToken(token.Access)
+	if e != nil {
+		t.Fatal(e)
+	} else if m.VerifyCSRF(token.Access, csrf) != nil {
+		t.Fatal(`csrf not verified`)
+	} else if m.VerifyCSRF(token.Refresh, csrf) != cryptoer.ErrCSRFTokenNotMatched {
+		t.Fatal(`csrf not bound to access`)
+	}
+
+	cookies := sessionhttp.NewCookies(m)
+	handler := cookies.Handler(sessionhttp.NewCSRF(m).HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := sessionhttp.CSRFToken(r.Context()); !ok {
+			t.Fatal(`csrf not in context`)
+		}
+	}))
+	w := httptest.NewRecorder()
+	cookies.Set(w, token)
+	set := w.Result().Cookies()
+	serve := func(method, csrf string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(method, `/`, nil)
+		for _, cookie := range set {
+			r.AddCookie(&http.Cookie{Name: cookie.Name, Value: cookie.Value})
+		}
+		if csrf != `` {
+			r.Header.Set(`X-CSRF-Token`, csrf)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+	w = serve(http.MethodGet, ``)
+	if w.Code != http.StatusOK || w.Header().Get(`X-CSRF-Token`) != csrf {
+		t.Fatal(`csrf not exposed`, w.Code)
+	}
+	w = serve(http.MethodPost, ``)
+	if w.Code != http.StatusForbidden {
+		t.Fatal(`missing csrf accepted`, w.Code)
+	}
+	w = serve(http.MethodPost, csrf)
+	if w.Code != http.StatusOK {
+		t.Fatal(`csrf rejected`, w.Code)
+	}
+
+	// access 過期後在同一請求中刷新，舊的 CSRF token 在本次請求中依然有效
+	time.Sleep(time.Second * 2)
+	w = serve(http.MethodPost, csrf)
+	if w.Code != http.StatusOK {
+		t.Fatal(`csrf rejected after refresh`, w.Code)
+	}
+	rotated := w.Header().Get(`X-CSRF-Token`)
+	if rotated == `` || rotated == csrf {
+		t.Fatal(`csrf not rotated`)
+	}
+	set = w.Result().Cookies()
+	w = serve(http.MethodPost, csrf)
+	if w.Code != http.StatusForbidden {
+		t.Fatal(`old csrf accepted`, w.Code)
+	}
+	w = serve(http.MethodPost, rotated)
+	if w.Code != http.StatusOK {
+		t.Fatal(`rotated csrf rejected`, w.Code)
+	}
+}
+func TestHTTPBindValue(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithBinding(sessionstore.BindValue(), nil),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`}, sessionstore.WithBindingValue(`cert-a`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	metadata := func(r *http.Request) []sessionstore.MetadataOption {
+		return append(sessionhttp.MetadataFromRequest(r),
+			sessionstore.WithBindingValue(r.Header.Get(`X-Client-Cert`)),
+		)
+	}
+	next := func(w http.ResponseWriter, r *http.Request) {}
+	cookies := sessionhttp.NewCookies(m, sessionhttp.WithCookieMetadata(metadata))
+	for _, handler := range []http.Handler{
+		sessionhttp.New(m, sessionhttp.WithMetadata(metadata)).HandlerFunc(next),
+		cookies.HandlerFunc(next),
+	} {
+		for _, cert := range []string{`cert-a`, `cert-b`} {
+			r := httptest.NewRequest(http.MethodGet, `/`, nil)
+			r.Header.Set(`Authorization`, `Bearer `+token.Access)
+			r.AddCookie(&http.Cookie{Name: `access_token`, Value: token.Access})
+			r.Header.Set(`X-Client-Cert`, cert)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if (w.Code == http.StatusOK) != (cert == `cert-a`) {
+				t.Fatal(`binding value not checked`, cert, w.Code)
+			}
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package sessionstore
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+)
+
+// 生成寫入 token 的唯一標識，返回的 id 只能包含 RawURLBase64 字符集
+type IDGenerator interface {
+	Generate() (id string, e error)
+}
+
+// 將函數適配爲 IDGenerator，通常用於在測試中提供確定的 id
+type IDGeneratorFunc func() (id string, e error)
+
+func (f IDGeneratorFunc) Generate() (id string, e error) {
+	return f()
+}
+
+type randomID int
+
+// 返回以 CSPRNG 生成 size 字節隨機數的 IDGenerator，如果 size < 16 則使用 16
+func NewRandomID(size int) IDGenerator {
+	if size < 16 {
+		size = 16
+	}
+	return randomID(size)
+}
+func (size randomID) Generate() (id string, e error) {
+	b := make([]byte, size)
+	_, e = rand.Read(b)
+	if e != nil {
+		return
+	}
+	id = base64.RawURLEncoding.EncodeToString(b)
+	return
+}
+
+type uuidV4 struct{}
+
+// 返回生成隨機 UUIDv4 的 IDGenerator
+func NewUUIDv4() IDGenerator {
+	return uuidV4{}
+}
+func (uuidV4) Generate() (id string, e error) {
+	u, e := uuid.NewRandom()
+	if e != nil {
+		return
+	}
+	id = u.String()
+	return
+}
+
+type uuidV7 struct{}
+
+// 返回生成 UUIDv7 的 IDGenerator，生成的 id 可以按創建時間排序
+func NewUUIDv7() IDGenerator {
+	return uuidV7{}
+}
+func (uuidV7) Generate() (id string, e error) {
+	var u uuid.UUID
+	_, e = rand.Read(u[6:])
+	if e != nil {
+		return
+	}
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], ms)
+	copy(u[:6], b[2:])
+	u[6] = (u[6] & 0x0f) | 0x70 // version 7
+	u[8] = (u[8] & 0x3f) | 0x80 // variant RFC 4122
+	id = u.String()
+	return
+}
+
+type ulid struct{}
+
+// 返回生成 ULID 的 IDGenerator，生成的 id 可以按創建時間排序
+func NewULID() IDGenerator {
+	return ulid{}
+}
+
+const crockford = `0123456789ABCDEFGHJKMNPQRSTVWXYZ`
+
+func (ulid) Generate() (id string, e error) {
+	// 48 位毫秒時間戳 + 80 位隨機數
+	var b [16]byte
+	_, e = rand.Read(b[6:])
+	if e != nil {
+		return
+	}
+	ms := uint64(time.Now().UnixNano() / int64(time.Millisecond))
+	var t [8]byte
+	binary.BigEndian.PutUint64(t[:], ms)
+	copy(b[:6], t[2:])
+
+	// 128 位 編碼爲 26 個 base32 字符，最高字符只使用 3 位
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	id = string(out[:])
+	return
+}
+
+// 生成 id 並確保它可以安全的寫入 token
+func (m *Manager) generateID() (id string, e error) {
+	id, e = m.opts.generator.Generate()
+	if e != nil {
+		return
+	} else if !cryptoer.IsValidKeyID(id) {
+		id = ``
+		e = cryptoer.ErrInvalidID
+	}
+	return
+}
@@ -0,0 +1,140 @@
+package sessionstore
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+	Kid string `json:"kid,omitempty"`
+}
+type jwtClaims struct {
+	// 用戶 id
+	Sub string `json:"sub"`
+	// 登入平臺
+	Platform string `json:"platform"`
+	// 多 session 模式下的 session 標識
+	Sid string `json:"sid,omitempty"`
+	Jti string `json:"jti"`
+	Iat int64  `json:"iat"`
+	Exp int64  `json:"exp"`
+}
+
+// 爲存儲 key 創建一個 JWS compact 格式的 JWT
+func (m *Manager) newJWT(key string, now, expiration time.Time) (token string, e error) {
+	id, platform, e := decodeKey(key)
+	if e != nil {
+		return
+	}
+	var sid string
+	if m.opts.multiple {
+		_, sid, _ = splitMultipleKey(key)
+	}
+	jti, e := m.generateID()
+	if e != nil {
+		return
+	}
+	kid, signKey, e := m.signingKey()
+	if e != nil {
+		return
+	}
+	header, e := json.Marshal(jwtHeader{
+		Alg: m.opts.method.Alg(),
+		Typ: `JWT`,
+		Kid: kid,
+	})
+	if e != nil {
+		return
+	}
+	claims, e := json.Marshal(jwtClaims{
+		Sub:      id,
+		Platform: platform,
+		Sid:      sid,
+		Jti:      jti,
+		Iat:      now.Unix(),
+		Exp:      expiration.Unix(),
+	})
+	if e != nil {
+		return
+	}
+	value := cryptoer.EncodeSegment(header) + `.` + cryptoer.EncodeSegment(claims)
+	sign, e := m.sign(signKey, value)
+	if e != nil {
+		return
+	}
+	token = value + `.` + sign
+	return
+}
+
+// 驗證 JWT 簽名 並返回 claims，value 爲 header.claims
+func (m *Manager) verifyJWT(value, sign string) (claims *jwtClaims, e error) {
+	i := strings.Index(value, `.`)
+	if i == -1 {
+		e = cryptoer.ErrInvalidToken
+		return
+	}
+	b, e := cryptoer.DecodeSegment(value[:i])
+	if e != nil {
+		e = cryptoer.ErrInvalidToken
+		return
+	}
+	var header jwtHeader
+	e = json.Unmarshal(b, &header)
+	if e != nil {
+		e = cryptoer.ErrInvalidToken
+		return
+	} else if header.Alg != m.opts.method.Alg() {
+		// 只接受配置的簽名算法，避免算法混淆攻擊
+		e = cryptoer.ErrInvalidToken
+		return
+	}
+	key, e := m.verifyingKey(header.Kid)
+	if e != nil {
+		return
+	}
+	e = m.verifySign(key, value, sign)
+	if e != nil {
+		return
+	}
+
+	b, e = cryptoer.DecodeSegment(value[i+1:])
+	if e != nil {
+		e = cryptoer.ErrInvalidToken
+		return
+	}
+	var tmp jwtClaims
+	e = json.Unmarshal(b, &tmp)
+	if e != nil {
+		e = cryptoer.ErrInvalidToken
+		return
+	}
+	claims = &tmp
+	return
+}
+
+// 驗證 JWT 並返回其關聯的存儲 key
+func (m *Manager) verifyJWTKey(token string) (key string, e error) {
+	i := strings.LastIndex(token, `.`)
+	if i == -1 {
+		e = cryptoer.ErrInvalidToken
+		return
+	}
+	claims, e := m.verifyJWT(token[:i], token[i+1:])
+	if e != nil {
+		return
+	}
+	key = encodeKey(claims.Sub, claims.Platform)
+	if m.opts.multiple {
+		if claims.Sid == `` {
+			e = cryptoer.ErrInvalidToken
+			return
+		}
+		key += `.` + claims.Sid
+	}
+	return
+}
@@ -0,0 +1,156 @@
+package sessionstore
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
+	"google.golang.org/protobuf/proto"
+)
+
+// 一個活躍的 session
+type Element struct {
+	// 登入平臺
+	Platform string
+	Token    *Token
+	Session  interface{}
+}
+
+// 返回指定用戶 id 的所有 session
+func (m *Manager) List(ctx context.Context, id string) (elements []*Element, e error) {
+	prefix := base64.RawURLEncoding.EncodeToString(StringToBytes(id)) + `.`
+	elements, e = m.list(ctx, prefix, nil)
+	return
+}
+
+// 返回指定用戶 id 在 指定平臺 platform 的所有 session
+func (m *Manager) ListPlatform(ctx context.Context, id, platform string) (elements []*Element, e error) {
+	prefix := encodeKey(id, platform)
+	elements, e = m.list(ctx, prefix, func(p string) bool {
+		return p == platform
+	})
+	return
+}
+func (m *Manager) list(ctx context.Context, prefix string, match func(platform string) bool) (elements []*Element, e error) {
+	if m.opts.stateless != nil {
+		e = cryptoer.ErrNotSupported
+		return
+	}
+	if m.opts.opaque {
+		elements, e = m.listOpaque(ctx, prefix, match)
+		return
+	}
+	var err error
+	e = m.opts.store.Range(ctx, prefix, func(key string, value []byte) bool {
+		var element *Element
+		element, err = m.decodeElement(key, value)
+		if err != nil {
+			return false
+		} else if element != nil && (match == nil || match(element.Platform)) {
+			elements = append(elements, element)
+		}
+		return true
+	})
+	if e == nil {
+		e = err
+	}
+	return
+}
+
+// 解析存儲的 session，如果 key 不是一個 session 返回 nil
+func (m *Manager) decodeElement(key string, value []byte) (element *Element, e error) {
+	strs := strings.Split(key, `.`)
+	if m.opts.multiple {
+		if len(strs) != 3 {
+			return
+		}
+	} else if len(strs) != 2 {
+		return
+	}
+	platform, e := base64.RawURLEncoding.DecodeString(strs[1])
+	if e != nil {
+		return
+	}
+	raw, e := unmarshalRaw(value)
+	if e != nil {
+		return
+	}
+	element, e = m.newElement(string(platform), raw)
+	return
+}
+
+// 返回 session 對應的 Element，如果 session 已經被刪除返回 nil
+func (m *Manager) newElement(platform string, raw *protoc_session.Raw) (element *Element, e error) {
+	token := m.rawToken(raw, ``)
+	if token.IsDeleted() {
+		return
+	}
+	session, _, e := m.unmarshalSession(raw)
+	if e != nil {
+		return
+	}
+	element = &Element{
+		Platform: platform,
+		Token:    token,
+		Session:  session,
+	}
+	return
+}
+
+// opaque 模式下 session 句柄不包含用戶信息，所以通過 用戶平臺 的索引查找 session
+func (m *Manager) listOpaque(ctx context.Context, prefix string, match func(platform string) bool) (elements []*Element, e error) {
+	handles, _, e := m.opaqueHandles(ctx, prefix)
+	if e != nil {
+		return
+	}
+	for _, handle := range handles {
+		var b []byte
+		b, e = m.opts.store.Get(ctx, handle)
+		if e != nil {
+			return
+		} else if b == nil {
+			continue
+		}
+		var raw *protoc_session.Raw
+		raw, e = unmarshalRaw(b)
+		if e != nil {
+			return
+		} else if match != nil && !match(raw.Platform) {
+			continue
+		}
+		var element *Element
+		element, e = m.newElement(raw.Platform, raw)
+		if e != nil {
+			return
+		} else if element != nil {
+			elements = append(elements, element)
+		}
+	}
+	return
+}
+
+// 返回 prefix 下所有索引記錄的 key 以及其中的 session 句柄
+func (m *Manager) opaqueHandles(ctx context.Context, prefix string) (handles, keys []string, e error) {
+	var err error
+	e = m.opts.store.Range(ctx, prefix, func(key string, value []byte) bool {
+		if strings.Count(key, `.`) != 1 {
+			return true
+		}
+		var index protoc_session.Index
+		err = proto.Unmarshal(value, &index)
+		if err != nil {
+			return false
+		}
+		keys = append(keys, key)
+		for _, item := range index.Items {
+			handles = append(handles, item.Id)
+		}
+		return true
+	})
+	if e == nil {
+		e = err
+	}
+	return
+}
@@ -7,7 +7,6 @@ import (
 	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
 
 	"github.com/powerpuffpenguin/sessionstore/cryptoer"
@@ -18,6 +17,8 @@ import (
 type Manager struct {
 	coder Coder
 	opts  *options
+	// 未使用密鑰環時的 簽名密鑰 和 驗證密鑰，緩存解析後的密鑰
+	key, verify *cryptoer.Key
 }
 
 func New(coder Coder, opt ...Option) (m *Manager) {
@@ -31,16 +32,30 @@ func New(coder Coder, opt ...Option) (m *Manager) {
 	if opts.store == nil {
 		opts.store = store.NewMemory(10000)
 	}
+	if opts.opaque {
+		// JWT 的 claims 包含用戶信息
+		opts.jwt = false
+	}
+	if opts.jwt {
+		// exp 簽名在 token 中 無法滑動
+		opts.sliding = 0
+	}
+	verify := opts.verify
+	if verify == nil {
+		verify = opts.key
+	}
 	return &Manager{
-		coder: coder,
-		opts:  &opts,
+		coder:  coder,
+		opts:   &opts,
+		key:    cryptoer.NewKey(opts.key),
+		verify: cryptoer.NewKey(verify),
 	}
 }
 func (m *Manager) Close() error {
 	return m.opts.store.Close()
 }
 
-// 驗證簽名是否有效
+// 驗證簽名是否有效，JWT 模式下返回的 playdata 爲 header.claims
 func (m *Manager) Verify(token string) (playdata string, e error) {
 	i := strings.LastIndex(token, `.`)
 	if i == -1 {
@@ -50,26 +65,104 @@ func (m *Manager) Verify(token string) (playdata string, e error) {
 	value := token[:i]
 	sign := token[i+1:]
 
-	e = m.opts.method.Verify(m.opts.key, StringToBytes(value), sign)
-	if e != nil {
+	if m.opts.jwt {
+		// header.claims.sign
+		_, e = m.verifyJWT(value, sign)
+		if e == nil {
+			playdata = value
+		}
 		return
 	}
-	playdata = value
+	var key *cryptoer.Key
+	if m.opts.keys != nil {
+		// playdata.kid.sign
+		j := strings.LastIndex(value, `.`)
+		if j == -1 {
+			e = cryptoer.ErrInvalidToken
+			return
+		}
+		key, e = m.verifyingKey(value[j+1:])
+		if e != nil {
+			return
+		}
+		playdata = value[:j]
+	} else {
+		key, _ = m.verifyingKey(``)
+		playdata = value
+	}
+
+	e = m.verifySign(key, value, sign)
+	if e != nil {
+		playdata = ``
+	}
 	return
 }
 
 // 簽名數據
 func (m *Manager) Sin(playdata string) (token string, e error) {
-	sign, e := m.opts.method.Sign(m.opts.key, StringToBytes(playdata))
+	id, key, e := m.signingKey()
+	if e != nil {
+		return
+	} else if id != `` {
+		playdata += `.` + id
+	}
+	sign, e := m.sign(key, playdata)
 	if e != nil {
 		return
 	}
 	return playdata + `.` + sign, nil
 }
 
-// 返回 token 關聯的 後端原始數據
-func (m *Manager) GetRaw(ctx context.Context, access string) (key string, token *Token, b []byte, e error) {
-	playdata, e := m.Verify(access)
+// 使用 key 爲 value 簽名，簽名算法實現了 cryptoer.KeySigningMethod 時使用緩存的解析結果
+func (m *Manager) sign(key *cryptoer.Key, value string) (string, error) {
+	if method, ok := m.opts.method.(cryptoer.KeySigningMethod); ok {
+		return method.SignKey(key, StringToBytes(value))
+	}
+	return m.opts.method.Sign(key.Bytes(), StringToBytes(value))
+}
+
+// 使用 key 驗證 value 的簽名，無法解碼的簽名返回 cryptoer.ErrInvalidToken
+func (m *Manager) verifySign(key *cryptoer.Key, value, sign string) (e error) {
+	if method, ok := m.opts.method.(cryptoer.KeySigningMethod); ok {
+		e = method.VerifyKey(key, StringToBytes(value), sign)
+	} else {
+		e = m.opts.method.Verify(key.Bytes(), StringToBytes(value), sign)
+	}
+	var corrupt base64.CorruptInputError
+	if errors.As(e, &corrupt) {
+		e = cryptoer.ErrInvalidToken
+	}
+	return
+}
+
+// 返回用於簽名的 密鑰 id 和 密鑰，沒有使用密鑰環時 id 爲空字符串
+func (m *Manager) signingKey() (id string, key *cryptoer.Key, e error) {
+	if m.opts.keys == nil {
+		key = m.key
+		return
+	}
+	id, key, e = m.opts.keys.CurrentKey()
+	return
+}
+
+// 返回 密鑰 id 對應的驗證簽名密鑰，
+// 密鑰不存在或已經退役時返回 cryptoer.ErrKeyNotFound 或 cryptoer.ErrKeyRetired
+func (m *Manager) verifyingKey(id string) (key *cryptoer.Key, e error) {
+	if m.opts.keys == nil {
+		key = m.verify
+		return
+	}
+	key, e = m.opts.keys.GetKey(id)
+	return
+}
+
+// 驗證簽名並返回 token 關聯的存儲 key
+func (m *Manager) verifyKey(token string) (key string, e error) {
+	if m.opts.jwt {
+		key, e = m.verifyJWTKey(token)
+		return
+	}
+	playdata, e := m.Verify(token)
 	if e != nil {
 		return
 	}
@@ -79,44 +172,91 @@ func (m *Manager) GetRaw(ctx context.Context, access string) (key string, token
 		return
 	}
 	key = playdata[:i]
+	return
+}
+
+// 返回 token 關聯的 後端原始數據
+func (m *Manager) GetRaw(ctx context.Context, access string) (key string, token *Token, b []byte, e error) {
+	if m.opts.stateless != nil {
+		var st *protoc_session.Stateless
+		st, token, e = m.statelessOpen(ctx, access)
+		if e == nil {
+			key = encodeKey(st.Id, st.Platform)
+			b = st.Data
+		}
+		return
+	}
+	key, raw, _, e := m.getRaw(ctx, access, false)
+	if e != nil {
+		return
+	}
+	token = m.accessToken(raw, access)
+	b = raw.Data
+	return
+}
 
-	b, e = m.opts.store.Get(ctx, key)
+// 返回 token 關聯的 存儲 key 和 存儲記錄，b 爲記錄編碼後的原始數據，
+// 準備 CompareAndSwap 時 latest 爲 true 從寫入節點讀取最新數據
+func (m *Manager) getRaw(ctx context.Context, access string, latest bool) (key string, raw *protoc_session.Raw, b []byte, e error) {
+	key, e = m.verifyKey(access)
+	if e != nil {
+		return
+	}
+	if latest {
+		b, e = getLatest(ctx, m.opts.store, key)
+	} else {
+		b, e = m.opts.store.Get(ctx, key)
+	}
 	if e != nil {
 		return
 	} else if b == nil {
 		e = cryptoer.ErrNotExistsToken
 		return
 	}
-	var raw protoc_session.Raw
-	e = proto.Unmarshal(b, &raw)
+	raw, e = unmarshalRaw(b)
+	if e != nil {
+		return
+	} else if !m.matchToken(raw.Token.Access, access) && !m.inGrace(raw, access) {
+		raw = nil
+		e = cryptoer.ErrNotExistsToken
+		return
+	} else if m.isIdle(raw, time.Now()) {
+		raw = nil
+		e = m.opts.store.Del(ctx, key)
+		if e == nil {
+			e = cryptoer.ErrIdleTimeout
+		}
+		return
+	}
+	return
+}
+func unmarshalRaw(b []byte) (raw *protoc_session.Raw, e error) {
+	var tmp protoc_session.Raw
+	e = proto.Unmarshal(b, &tmp)
 	if e != nil {
 		return
 	}
-	if raw.Token == nil {
+	if tmp.Token == nil {
 		e = errors.New(`raw.Token nil`)
 		return
-	} else if raw.Data == nil {
+	} else if tmp.Data == nil {
 		e = errors.New(`raw.Data nil`)
 		return
-	} else if raw.Token.Access != access {
-		e = cryptoer.ErrNotExistsToken
-		return
 	}
-	token = NewToken(
-		raw.Token.Access, raw.Token.Refresh,
-		raw.Token.AccessDeadline, raw.Token.RefreshDeadline,
-		raw.Token.Deadline,
-	)
-	b = raw.Data
+	raw = &tmp
 	return
 }
 
-// 返回 token 關聯的 session 數據
-func (m *Manager) Get(ctx context.Context, access string) (token *Token, session interface{}, e error) {
-	_, token, b, e := m.GetRaw(ctx, access)
+// 返回 token 關聯的 session 數據，啓用 WithBinding 時 opt 提供當前請求的客戶端信息
+func (m *Manager) Get(ctx context.Context, access string, opt ...MetadataOption) (token *Token, session interface{}, e error) {
+	if m.opts.stateless != nil {
+		return m.statelessGet(ctx, access, opt)
+	}
+	key, raw, _, e := m.getRaw(ctx, access, false)
 	if e != nil {
 		return
 	}
+	token = m.accessToken(raw, access)
 	// token
 	if token.IsDeleted() {
 		e = cryptoer.ErrNotExistsToken
@@ -125,154 +265,397 @@ func (m *Manager) Get(ctx context.Context, access string) (token *Token, session
 		e = cryptoer.ErrExpired
 		return
 	}
+	e = m.verifyBinding(ctx, raw.Metadata, opt)
+	if e != nil {
+		if e == cryptoer.ErrReauthRequired {
+			e = m.reauth(ctx, key, nil)
+		}
+		token = nil
+		return
+	}
+	now := time.Now()
+	if (m.matchToken(raw.Token.Access, access) && m.needSlide(token, now)) || m.needActive(raw, now) {
+		var t *Token
+		t, e = m.touch(ctx, access)
+		if e != nil {
+			return
+		} else if t != nil {
+			token = t
+		}
+	}
 
 	// session
-	session, e = m.coder.Unmarshal(b)
+	session, upgraded, e := m.unmarshalSession(raw)
+	if e == nil && upgraded {
+		m.writeBack(ctx, access)
+	}
 	return
 }
 
+// 更新 token 關聯的 session 數據，token 和 過期時間 保持不變
+func (m *Manager) Update(ctx context.Context, access string, session interface{}) (e error) {
+	_, e = m.Modify(ctx, access, func(interface{}) (interface{}, error) {
+		return session, nil
+	})
+	return
+}
+
+// 以 f 的返回值 原子的替換 token 關聯的 session 數據，token 和 過期時間 保持不變。
+// 如果 session 在此期間被其它請求修改，會重新讀取 session 並再次調用 f，
+// 重試次數超過 WithRetry 設定值後返回 cryptoer.ErrConflict
+func (m *Manager) Modify(ctx context.Context, access string, f func(session interface{}) (interface{}, error)) (session interface{}, e error) {
+	if m.opts.stateless != nil {
+		e = cryptoer.ErrNotSupported
+		return
+	}
+	e = m.modifyRaw(ctx, access, func(key string, raw *protoc_session.Raw) (deadline time.Time, e error) {
+		token := m.rawToken(raw, access)
+		if token.IsDeleted() {
+			e = cryptoer.ErrNotExistsToken
+			return
+		} else if token.IsExpired() {
+			e = cryptoer.ErrExpired
+			return
+		}
+		// unmarshal session
+		session, _, e = m.unmarshalSession(raw)
+		if e != nil {
+			return
+		}
+		session, e = f(session)
+		if e != nil {
+			return
+		}
+		// marshal session
+		e = m.marshalSession(raw, session)
+		if e != nil {
+			return
+		}
+		deadline = time.Unix(token.RefreshDeadline, 0)
+		return
+	})
+	if e != nil {
+		session = nil
+	}
+	return
+}
+
+// modifyRaw 的 f 返回此錯誤表示不需要寫回存儲
+var errNotModified = errors.New(`not modified`)
+
+// 讀取 access 關聯的記錄 交給 f 修改，之後以 CompareAndSwap 寫回存儲，發生衝突時重試
+func (m *Manager) modifyRaw(ctx context.Context, access string, f func(key string, raw *protoc_session.Raw) (deadline time.Time, e error)) (e error) {
+	var (
+		key      string
+		raw      *protoc_session.Raw
+		old, b   []byte
+		deadline time.Time
+		swapped  bool
+	)
+	for i := 0; ; i++ {
+		key, raw, old, e = m.getRaw(ctx, access, true)
+		if e != nil {
+			return
+		}
+		deadline, e = f(key, raw)
+		if e != nil {
+			return
+		}
+		// 每次寫入都是一次活動
+		raw.Active = time.Now().Unix()
+		b, e = proto.Marshal(raw)
+		if e != nil {
+			return
+		}
+		swapped, e = m.opts.store.CompareAndSwap(ctx, key, old, b, deadline)
+		if e != nil || swapped {
+			return
+		} else if i >= m.opts.retry {
+			e = cryptoer.ErrConflict
+			return
+		}
+	}
+}
+
 // 刪除 token
 func (m *Manager) Delete(ctx context.Context, access string) (e error) {
-	playdata, e := m.Verify(access)
-	if e != nil {
+	if m.opts.stateless != nil {
+		var st *protoc_session.Stateless
+		st, e = m.openStateless(statelessAccess, access)
+		if e == nil {
+			e = m.statelessRevoke(ctx, encodeKey(st.Id, st.Platform))
+		}
 		return
 	}
-	i := strings.LastIndex(playdata, `.`)
-	if i == -1 {
-		e = cryptoer.ErrInvalidToken
+	key, e := m.verifyKey(access)
+	if e != nil {
 		return
 	}
-	key := playdata[:i]
 	e = m.opts.store.Del(context.Background(), key)
 	return
 }
 
 // 刪除指定 用戶 id 的所有 session
 func (m *Manager) DeleteID(ctx context.Context, id string) (e error) {
+	if m.opts.stateless != nil {
+		e = m.statelessRevoke(ctx, base64.RawURLEncoding.EncodeToString(StringToBytes(id)))
+		return
+	}
 	prefix := base64.RawURLEncoding.EncodeToString(StringToBytes(id)) + `.`
+	if m.opts.opaque {
+		var keys []string
+		_, keys, e = m.opaqueHandles(ctx, prefix)
+		if e != nil {
+			return
+		}
+		for _, key := range keys {
+			e = m.clearIndex(ctx, key)
+			if e != nil {
+				return
+			}
+		}
+		return
+	}
 	e = m.opts.store.DelPrefix(ctx, prefix)
 	return
 }
 
 // 刪除指定用戶 id 在 指定平臺 platform 的所有 session
 func (m *Manager) DeletePlatform(ctx context.Context, id, platform string) (e error) {
-	prefix := base64.RawURLEncoding.EncodeToString(StringToBytes(id)) + `.` +
-		base64.RawURLEncoding.EncodeToString(StringToBytes(platform)) + `.`
-	e = m.opts.store.DelPrefix(ctx, prefix)
+	if m.opts.stateless != nil {
+		e = m.statelessRevoke(ctx, encodeKey(id, platform))
+		return
+	}
+	key := encodeKey(id, platform)
+	if m.opts.opaque {
+		e = m.clearIndex(ctx, key)
+		return
+	}
+	e = m.opts.store.Del(ctx, key)
+	if e != nil {
+		return
+	}
+	e = m.opts.store.DelPrefix(ctx, key+`.`)
+	return
+}
+
+// 爲存儲 key 創建一個在 expiration 過期的 token
+func (m *Manager) newToken(key string, now, expiration time.Time) (token string, e error) {
+	if m.opts.jwt {
+		token, e = m.newJWT(key, now, expiration)
+	} else {
+		token, e = m.NewToken(key)
+	}
 	return
 }
 
 // 創建一個 token
 func (m *Manager) NewToken(prefix string) (token string, e error) {
-	u, e := uuid.NewUUID()
+	id, e := m.generateID()
 	if e != nil {
 		return
 	}
-	playdata := prefix + `.` + base64.RawURLEncoding.EncodeToString(u[:])
+	playdata := prefix + `.` + id
 	token, e = m.Sin(playdata)
 	return
 }
 
 // 創建 session 關聯的 token
-func (m *Manager) Put(ctx context.Context, id, platform string, session interface{}) (token *Token, e error) {
+func (m *Manager) Put(ctx context.Context, id, platform string, session interface{}, opt ...MetadataOption) (token *Token, e error) {
+	if m.opts.stateless != nil {
+		return m.statelessPut(ctx, id, platform, session, opt)
+	}
+	now := time.Now()
+	prefix := encodeKey(id, platform)
+	key := prefix
+	var indexID string
+	if m.indexed() {
+		key, indexID, e = m.newIndexedKey(prefix)
+		if e != nil {
+			return
+		}
+	}
 	// create token
-	key := base64.RawURLEncoding.EncodeToString(StringToBytes(id)) + `.` +
-		base64.RawURLEncoding.EncodeToString(StringToBytes(platform))
-	access, e := m.NewToken(key)
+	accessDeadline := now.Add(m.opts.access)
+	refreshDeadline := now.Add(m.opts.refresh)
+	access, e := m.newToken(key, now, accessDeadline)
 	if e != nil {
 		return
 	}
-	refresh, e := m.NewToken(key)
+	refresh, e := m.newToken(key, now, refreshDeadline)
 	if e != nil {
 		return
 	}
-	now := time.Now()
-	refreshDeadline := now.Add(m.opts.refresh)
 	var deadline int64
 	if m.opts.deadline != 0 {
 		deadline = now.Add(m.opts.deadline).Unix()
 	}
 	token = NewToken(access, refresh,
-		now.Add(m.opts.access).Unix(), refreshDeadline.Unix(),
+		accessDeadline.Unix(), refreshDeadline.Unix(),
 		deadline,
 	)
-	// marshal session
-	b, e := m.coder.Marshal(session)
-	if e != nil {
-		return
-	}
-
-	// marshal
-	b, e = proto.Marshal(&protoc_session.Raw{
+	raw := &protoc_session.Raw{
 		Token: &protoc_session.Token{
-			Access:          token.Access,
-			Refresh:         token.Refresh,
+			Access:          m.storedToken(token.Access),
+			Refresh:         m.storedToken(token.Refresh),
 			AccessDeadline:  token.AccessDeadline,
 			RefreshDeadline: token.RefreshDeadline,
 			Deadline:        token.Deadline,
 		},
-		Data: b,
-	})
+		Active:   now.Unix(),
+		Id:       id,
+		Platform: platform,
+		Metadata: m.applyMetadata(nil, now, opt),
+	}
+	token.Metadata = newMetadata(raw.Metadata, raw.Active)
+	// marshal session
+	e = m.marshalSession(raw, session)
 	if e != nil {
 		return
 	}
 
-	e = m.opts.store.Put(ctx, key, b, refreshDeadline)
-	return
-}
-
-func (m *Manager) Refresh(ctx context.Context, access, refresh string) (token *Token, session interface{}, e error) {
-	key, token, b, e := m.GetRaw(ctx, access)
+	// marshal
+	b, e := proto.Marshal(raw)
 	if e != nil {
 		return
 	}
 
-	// token
-	if token.IsDeleted() {
-		e = cryptoer.ErrNotExistsToken
-		return
-	} else if refresh != token.Refresh {
-		e = cryptoer.ErrRefreshTokenNotMatched
+	e = m.opts.store.Put(ctx, key, b, refreshDeadline)
+	if e != nil {
+		token = nil
 		return
-	} else if !token.CanRefresh() {
-		e = cryptoer.ErrCannotRefresh
+	} else if indexID == `` {
 		return
 	}
-	access, e = m.NewToken(key)
+	e = m.addIndex(ctx, prefix, indexID, now, refreshDeadline)
 	if e != nil {
-		return
+		token = nil
+		m.opts.store.Del(ctx, key)
 	}
-	refresh, e = m.NewToken(key)
-	if e != nil {
-		return
+	return
+}
+
+// 使用 refresh token 簽發一組新的 token，opt 可以更新 session 元數據
+func (m *Manager) Refresh(ctx context.Context, access, refresh string, opt ...MetadataOption) (token *Token, session interface{}, e error) {
+	if m.opts.stateless != nil {
+		return m.statelessRefresh(ctx, access, refresh, opt)
 	}
-	now := time.Now()
-	refreshDeadline := now.Add(m.opts.refresh)
-	token = NewToken(access, refresh,
-		now.Add(m.opts.access).Unix(), refreshDeadline.Unix(),
-		token.Deadline,
+	var (
+		refreshKey      string
+		refreshRaw      *protoc_session.Raw
+		refreshDeadline time.Time
 	)
-	// unmarshal
-	session, e = m.coder.Unmarshal(b)
-	if e != nil {
-		return
-	}
+	e = m.modifyRaw(ctx, access, func(key string, raw *protoc_session.Raw) (deadline time.Time, e error) {
+		refreshKey = key
+		refreshRaw = raw
+		token = m.rawToken(raw, access)
+		// token
+		if token.IsDeleted() {
+			e = cryptoer.ErrNotExistsToken
+			return
+		}
+		if !m.matchToken(raw.Token.Access, access) {
+			// 寬限期內使用上一組 token 刷新 返回當前 token
+			if !m.matchToken(raw.Previous.Refresh, refresh) {
+				e = cryptoer.ErrRefreshTokenNotMatched
+				return
+			}
+			// 只有持有 refresh token 的請求才檢查綁定，避免只持有 access token 就能觸發 reauth 刪除 session
+			e = m.verifyBinding(ctx, raw.Metadata, opt)
+			if e != nil {
+				return
+			} else if m.opts.hash != nil {
+				// 存儲中沒有當前 token 的明文
+				e = cryptoer.ErrNotSupported
+				return
+			}
+			session, _, e = m.unmarshalSession(raw)
+			if e == nil {
+				e = errNotModified
+			}
+			return
+		} else if !m.matchToken(raw.Token.Refresh, refresh) {
+			if m.opts.reuse > 0 && m.isRotated(raw.Rotated, refresh) {
+				e = errRefreshTokenReused
+			} else {
+				e = cryptoer.ErrRefreshTokenNotMatched
+			}
+			return
+		} else if !token.CanRefresh() {
+			e = cryptoer.ErrCannotRefresh
+			return
+		}
+		e = m.verifyBinding(ctx, raw.Metadata, opt)
+		if e != nil {
+			return
+		}
+		now := time.Now()
+		accessDeadline := now.Add(m.opts.access)
+		refreshDeadline = now.Add(m.opts.refresh)
+		access, e := m.newToken(key, now, accessDeadline)
+		if e != nil {
+			return
+		}
+		refresh, e := m.newToken(key, now, refreshDeadline)
+		if e != nil {
+			return
+		}
+		token = NewToken(access, refresh,
+			accessDeadline.Unix(), refreshDeadline.Unix(),
+			token.Deadline,
+		)
+		// unmarshal
+		session, _, e = m.unmarshalSession(raw)
+		if e != nil {
+			return
+		}
+		raw.Metadata = m.applyMetadata(raw.Metadata, now, opt)
+		raw.Metadata.Refreshed = now.Unix()
+		raw.Active = now.Unix()
+		token.Metadata = newMetadata(raw.Metadata, raw.Active)
 
-	// marshal
-	raw := &protoc_session.Raw{
-		Token: &protoc_session.Token{
-			Access:          token.Access,
-			Refresh:         token.Refresh,
+		if m.opts.reuse > 0 {
+			raw.Rotated = appendRotated(raw.Rotated, raw.Token.Refresh, m.opts.reuse)
+		}
+		if m.opts.grace > 0 {
+			raw.Previous = &protoc_session.Previous{
+				Access:         raw.Token.Access,
+				Refresh:        raw.Token.Refresh,
+				Deadline:       now.Add(m.opts.grace).Unix(),
+				AccessDeadline: raw.Token.AccessDeadline,
+			}
+		}
+		raw.Token = &protoc_session.Token{
+			Access:          m.storedToken(token.Access),
+			Refresh:         m.storedToken(token.Refresh),
 			AccessDeadline:  token.AccessDeadline,
 			RefreshDeadline: token.RefreshDeadline,
 			Deadline:        token.Deadline,
-		},
-		Data: b,
-	}
-	b, e = proto.Marshal(raw)
-	if e != nil {
+		}
+		deadline = refreshDeadline
+		return
+	})
+	if e == errNotModified {
+		e = nil
+		return
+	} else if e != nil {
+		token = nil
+		session = nil
+		if e == cryptoer.ErrReauthRequired {
+			e = m.reauth(ctx, refreshKey, nil)
+		} else if m.opts.reuse > 0 {
+			if e == errRefreshTokenReused {
+				e = m.revokeReused(ctx, refreshKey, refreshRaw)
+			} else if e == cryptoer.ErrNotExistsToken {
+				e = m.checkReused(ctx, access, refresh)
+			}
+		}
 		return
 	}
-	e = m.opts.store.Put(ctx, key, b, refreshDeadline)
+	if m.indexed() {
+		if prefix, id, ok := m.indexOf(refreshKey, refreshRaw); ok {
+			e = m.touchIndex(ctx, prefix, id, refreshDeadline)
+		}
+	}
 	return
 }
@@ -1,14 +1,29 @@
 package sessionstore_test
 
 import (
+	"bytes"
 	"context"
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
 	"encoding/json"
+	"encoding/pem"
 	"fmt"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/powerpuffpenguin/sessionstore"
 	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
 	"github.com/powerpuffpenguin/sessionstore/store"
 	"github.com/powerpuffpenguin/sessionstore/store/bbolt"
 )
@@ -127,7 +142,9 @@ func testManagerRefresh(t *testing.T, store sessionstore.Store) {
 		)
 		w0 = time.Second * 3
 		w1 = time.Second * 3
-		w2 = time.Second * 4
+		// Deadline 以秒記錄且在最後一秒內仍然可以刷新，
+		// 所以 w0+w1+w2 必須越過 deadline 至少一秒，否則測試會隨機失敗
+		w2 = time.Second * 5
 	} else {
 		opts = append(opts,
 			sessionstore.WithAccess(time.Second*1),
@@ -270,3 +287,1288 @@ func testManagerRefresh(t *testing.T, store sessionstore.Store) {
 func TestMemoryRefresh(t *testing.T) {
 	testManagerRefresh(t, store.NewMemory(3))
 }
+func TestMemoryMultiple(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithMultiple(2, sessionstore.EvictOldest),
+	)
+	tokens := make([]*sessionstore.Token, 0, 3)
+	for i := 0; i < 3; i++ {
+		token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: fmt.Sprint(`name `, i)})
+		if e != nil {
+			t.Fatal(e)
+		}
+		tokens = append(tokens, token)
+	}
+	_, _, e := m.Get(ctx, tokens[0].Access)
+	if e != cryptoer.ErrNotExistsToken {
+		t.Fatal(`oldest session not evicted`, e)
+	}
+	for i, token := range tokens[1:] {
+		_, s, e := m.Get(ctx, token.Access)
+		if e != nil {
+			t.Fatal(e)
+		}
+		if s.(*Session).Name != fmt.Sprint(`name `, i+1) {
+			t.Fatal(`Name not equal`)
+		}
+	}
+
+	m = sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithMultiple(2, sessionstore.EvictReject),
+	)
+	tokens = tokens[:0]
+	for i := 0; i < 2; i++ {
+		token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+		if e != nil {
+			t.Fatal(e)
+		}
+		tokens = append(tokens, token)
+	}
+	_, e = m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != cryptoer.ErrTooManySessions {
+		t.Fatal(`not ErrTooManySessions`, e)
+	}
+	e = m.Delete(ctx, tokens[0].Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = m.DeletePlatform(ctx, `1`, `web`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, token := range []*sessionstore.Token{tokens[1], token} {
+		_, _, e = m.Get(ctx, token.Access)
+		if e != cryptoer.ErrNotExistsToken {
+			t.Fatal(`not ErrNotExistsToken`, e)
+		}
+	}
+}
+func testManagerList(t *testing.T, store sessionstore.Store) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store),
+		sessionstore.WithMultiple(0, sessionstore.EvictOldest),
+	)
+	platforms := []string{`web`, `web`, `webx`, `android`}
+	for _, platform := range platforms {
+		_, e := m.Put(ctx, `1`, platform, &Session{ID: `1`, Name: platform})
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	_, e := m.Put(ctx, `2`, `web`, &Session{ID: `2`})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	elements, e := m.List(ctx, `1`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(elements) != len(platforms) {
+		t.Fatal(`List count not equal`, len(elements))
+	}
+	for _, element := range elements {
+		s := element.Session.(*Session)
+		if s.ID != `1` || s.Name != element.Platform {
+			t.Fatal(`List session not equal`)
+		}
+	}
+
+	elements, e = m.ListPlatform(ctx, `1`, `web`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(elements) != 2 {
+		t.Fatal(`ListPlatform count not equal`, len(elements))
+	}
+	for _, element := range elements {
+		if element.Platform != `web` {
+			t.Fatal(`ListPlatform platform not equal`)
+		}
+	}
+}
+func TestMemoryList(t *testing.T) {
+	testManagerList(t, store.NewMemory(100))
+}
+func TestMemoryUpdate(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: `name`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = m.Update(ctx, token.Access, &Session{ID: `1`, Name: `new name`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	t0, s, e := m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if t0.Refresh != token.Refresh || t0.AccessDeadline != token.AccessDeadline {
+		t.Fatal(`Token changed`)
+	}
+	if s.(*Session).Name != `new name` {
+		t.Fatal(`Name not updated`)
+	}
+}
+func TestMemoryModify(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithRetry(0),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: `name`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	s, e := m.Modify(ctx, token.Access, func(session interface{}) (interface{}, error) {
+		s := session.(*Session)
+		s.Name += ` modify`
+		return s, nil
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if s.(*Session).Name != `name modify` {
+		t.Fatal(`Name not modified`)
+	}
+
+	_, e = m.Modify(ctx, token.Access, func(session interface{}) (interface{}, error) {
+		e := m.Update(ctx, token.Access, &Session{ID: `1`, Name: `concurrent`})
+		if e != nil {
+			t.Fatal(e)
+		}
+		return session, nil
+	})
+	if e != cryptoer.ErrConflict {
+		t.Fatal(`not ErrConflict`, e)
+	}
+	_, s, e = m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if s.(*Session).Name != `concurrent` {
+		t.Fatal(`concurrent update lost`)
+	}
+}
+func TestMemoryRefreshReuse(t *testing.T) {
+	ctx := context.Background()
+	var reused string
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithRefreshReuse(5, func(ctx context.Context, id, platform string) {
+			reused = id + `/` + platform
+		}),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	t0, _, e := m.Refresh(ctx, token.Access, token.Refresh)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Refresh(ctx, token.Access, token.Refresh)
+	if e != cryptoer.ErrRefreshTokenReused {
+		t.Fatal(`not ErrRefreshTokenReused`, e)
+	}
+	if reused != `1/web` {
+		t.Fatal(`reused hook not called`, reused)
+	}
+	_, _, e = m.Get(ctx, t0.Access)
+	if e != cryptoer.ErrNotExistsToken {
+		t.Fatal(`session not revoked`, e)
+	}
+}
+func TestMemoryRefreshGrace(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithRefreshGrace(time.Minute),
+		sessionstore.WithRefreshReuse(5, nil),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	t0, _, e := m.Refresh(ctx, token.Access, token.Refresh)
+	if e != nil {
+		t.Fatal(e)
+	}
+	prev, _, e := m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(`previous access invalid in grace`, e)
+	}
+	if prev.Access != token.Access || prev.Refresh != `` {
+		t.Fatal(`get in grace returned the rotated token`)
+	}
+	t1, s, e := m.Refresh(ctx, token.Access, token.Refresh)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if t1.Access != t0.Access || t1.Refresh != t0.Refresh {
+		t.Fatal(`refresh in grace returned another token`)
+	}
+	if s.(*Session).ID != `1` {
+		t.Fatal(`ID not equal`)
+	}
+	_, _, e = m.Refresh(ctx, token.Access, token.Access)
+	if e != cryptoer.ErrRefreshTokenNotMatched {
+		t.Fatal(`not ErrRefreshTokenNotMatched`, e)
+	}
+
+	// 寬限期不會讓已經過期的 access token 重新生效
+	m = sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithAccess(time.Second),
+		sessionstore.WithRefreshGrace(time.Minute),
+	)
+	token, e = m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	time.Sleep(time.Second * 2)
+	t0, _, e = m.Refresh(ctx, token.Access, token.Refresh)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Get(ctx, token.Access)
+	if e != cryptoer.ErrExpired {
+		t.Fatal(`expired previous access accepted in grace`, e)
+	}
+	t1, _, e = m.Refresh(ctx, token.Access, token.Refresh)
+	if e != nil {
+		t.Fatal(e)
+	} else if t1.Access != t0.Access {
+		t.Fatal(`refresh in grace returned another token`)
+	}
+}
+func TestMemorySliding(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithAccess(time.Second*2),
+		sessionstore.WithRefresh(time.Second*4),
+		sessionstore.WithSliding(0.25),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	t0, _, e := m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if t0.AccessDeadline != token.AccessDeadline {
+		t.Fatal(`slide before fraction`)
+	}
+	time.Sleep(time.Millisecond * 1100)
+	t0, _, e = m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if t0.AccessDeadline <= token.AccessDeadline || t0.RefreshDeadline <= token.RefreshDeadline {
+		t.Fatal(`not slide`)
+	}
+	t1, _, e := m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if t1.AccessDeadline != t0.AccessDeadline {
+		t.Fatal(`AccessDeadline not stored`)
+	}
+}
+func TestMemorySlidingIndex(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithOpaque(true),
+		sessionstore.WithMultiple(1, sessionstore.EvictReject),
+		sessionstore.WithAccess(time.Second*2),
+		sessionstore.WithRefresh(time.Second*3),
+		sessionstore.WithSliding(0.25),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	// 滑動到原 refresh 有效期之後
+	for i := 0; i < 5; i++ {
+		time.Sleep(time.Millisecond * 1100)
+		_, _, e = m.Get(ctx, token.Access)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	if time.Now().Unix() <= token.RefreshDeadline {
+		t.Fatal(`not past original refresh deadline`)
+	}
+	elements, e := m.List(ctx, `1`)
+	if e != nil {
+		t.Fatal(e)
+	} else if len(elements) != 1 {
+		t.Fatal(`index expired before session`, len(elements))
+	}
+	_, e = m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != cryptoer.ErrTooManySessions {
+		t.Fatal(`not ErrTooManySessions`, e)
+	}
+	e = m.DeleteID(ctx, `1`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Get(ctx, token.Access)
+	if e != cryptoer.ErrNotExistsToken {
+		t.Fatal(`session alive after DeleteID`, e)
+	}
+}
+func TestMemorySlidingJWT(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithAccess(time.Second*2),
+		sessionstore.WithRefresh(time.Second*4),
+		sessionstore.WithSliding(0.25),
+		sessionstore.WithJWT(true),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	time.Sleep(time.Millisecond * 1100)
+	t0, _, e := m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if t0.AccessDeadline != token.AccessDeadline {
+		t.Fatal(`slide with jwt`)
+	}
+}
+func TestMemoryIdle(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithAccess(time.Second*10),
+		sessionstore.WithIdle(time.Second*3),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	for i := 0; i < 2; i++ {
+		time.Sleep(time.Millisecond * 1200)
+		_, _, e = m.Get(ctx, token.Access)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	time.Sleep(time.Millisecond * 4200)
+	_, _, e = m.Get(ctx, token.Access)
+	if e != cryptoer.ErrIdleTimeout {
+		t.Fatal(`not ErrIdleTimeout`, e)
+	}
+	_, _, e = m.Refresh(ctx, token.Access, token.Refresh)
+	if e != cryptoer.ErrNotExistsToken {
+		t.Fatal(`idle session not deleted`, e)
+	}
+}
+func TestMemoryKeys(t *testing.T) {
+	ctx := context.Background()
+	keys := cryptoer.NewKeyRing()
+	e := keys.Add(`k1`, []byte(`key 1`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithKeys(keys),
+	)
+	t1, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	e = keys.Add(`k2`, []byte(`key 2`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = keys.SetCurrent(`k2`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	t2, e := m.Put(ctx, `2`, `web`, &Session{ID: `2`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, token := range []*sessionstore.Token{t1, t2} {
+		_, _, e = m.Get(ctx, token.Access)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+
+	e = keys.Retire(`k1`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Get(ctx, t1.Access)
+	if e != cryptoer.ErrKeyRetired {
+		t.Fatal(`retired key accepted`, e)
+	}
+	_, _, e = m.Get(ctx, t2.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+}
+func TestAsymmetricMethods(t *testing.T) {
+	ctx := context.Background()
+	rsaKey, e := rsa.GenerateKey(rand.Reader, 2048)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, ed25519Key, e := ed25519.GenerateKey(rand.Reader)
+	if e != nil {
+		t.Fatal(e)
+	}
+	keys := map[cryptoer.SigningMethod]crypto.Signer{
+		cryptoer.SigningMethodEdDSA: ed25519Key,
+		cryptoer.SigningMethodRS256: rsaKey,
+		cryptoer.SigningMethodPS384: rsaKey,
+	}
+	for method, curve := range map[cryptoer.SigningMethod]elliptic.Curve{
+		cryptoer.SigningMethodES256: elliptic.P256(),
+		cryptoer.SigningMethodES384: elliptic.P384(),
+		cryptoer.SigningMethodES512: elliptic.P521(),
+	} {
+		key, e := ecdsa.GenerateKey(curve, rand.Reader)
+		if e != nil {
+			t.Fatal(e)
+		}
+		keys[method] = key
+	}
+	for method, signer := range keys {
+		private, e := x509.MarshalPKCS8PrivateKey(signer)
+		if e != nil {
+			t.Fatal(e)
+		}
+		public, e := x509.MarshalPKIXPublicKey(signer.Public())
+		if e != nil {
+			t.Fatal(e)
+		}
+		s := store.NewMemory(100)
+		m := sessionstore.New(Coder{},
+			sessionstore.WithStore(s),
+			sessionstore.WithMethod(method),
+			sessionstore.WithKey(pem.EncodeToMemory(&pem.Block{Type: `PRIVATE KEY`, Bytes: private})),
+		)
+		token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+		if e != nil {
+			t.Fatal(method.Alg(), e)
+		}
+		verifier := sessionstore.New(Coder{},
+			sessionstore.WithStore(s),
+			sessionstore.WithMethod(method),
+			sessionstore.WithKey(nil),
+			sessionstore.WithVerifyKey(public),
+		)
+		_, _, e = verifier.Get(ctx, token.Access)
+		if e != nil {
+			t.Fatal(method.Alg(), e)
+		}
+		_, e = verifier.Verify(token.Access[:len(token.Access)-4] + `AAAA`)
+		if e == nil {
+			t.Fatal(method.Alg(), `invalid signature accepted`)
+		}
+	}
+
+	// ES256 不接受 P-384 的密鑰
+	key, e := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	if e != nil {
+		t.Fatal(e)
+	}
+	public, e := x509.MarshalPKIXPublicKey(key.Public())
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = cryptoer.SigningMethodES256.Verify(public, []byte(`value`), cryptoer.EncodeSegment(make([]byte, 64)))
+	if e != cryptoer.ErrInvalidKey {
+		t.Fatal(`curve mismatch accepted`, e)
+	}
+}
+func TestMemoryJWT(t *testing.T) {
+	ctx := context.Background()
+	keys := cryptoer.NewKeyRing()
+	e := keys.Add(`k1`, []byte(`key 1`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithMethod(cryptoer.SigningMethodHS256),
+		sessionstore.WithKeys(keys),
+		sessionstore.WithMultiple(0, sessionstore.EvictOldest),
+		sessionstore.WithJWT(true),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	strs := strings.Split(token.Access, `.`)
+	if len(strs) != 3 {
+		t.Fatal(`not jws compact`)
+	}
+	var header map[string]interface{}
+	var claims map[string]interface{}
+	for i, v := range []interface{}{&header, &claims} {
+		b, e := cryptoer.DecodeSegment(strs[i])
+		if e != nil {
+			t.Fatal(e)
+		}
+		e = json.Unmarshal(b, v)
+		if e != nil {
+			t.Fatal(e)
+		}
+	}
+	if header[`alg`] != `HS256` || header[`kid`] != `k1` {
+		t.Fatal(`header not matched`, header)
+	}
+	if claims[`sub`] != `1` || claims[`platform`] != `web` ||
+		claims[`exp`].(float64) != float64(token.AccessDeadline) {
+		t.Fatal(`claims not matched`, claims)
+	}
+
+	t0, _, e := m.Refresh(ctx, token.Access, token.Refresh)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, s, e := m.Get(ctx, t0.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if s.(*Session).ID != `1` {
+		t.Fatal(`ID not equal`)
+	}
+	e = m.DeleteID(ctx, `1`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Get(ctx, t0.Access)
+	if e != cryptoer.ErrNotExistsToken {
+		t.Fatal(`not ErrNotExistsToken`, e)
+	}
+}
+func TestStateless(t *testing.T) {
+	ctx := context.Background()
+	block, e := aes.NewCipher(make([]byte, 32))
+	if e != nil {
+		t.Fatal(e)
+	}
+	aead, e := cipher.NewGCM(block)
+	if e != nil {
+		t.Fatal(e)
+	}
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithStateless(aead),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: `name`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, s, e := m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if s.(*Session).Name != `name` {
+		t.Fatal(`Name not equal`)
+	}
+	_, _, e = m.Get(ctx, token.Refresh)
+	if e != cryptoer.ErrInvalidToken {
+		t.Fatal(`refresh used as access`, e)
+	}
+
+	t0, _, e := m.Refresh(ctx, token.Access, token.Refresh)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = m.DeletePlatform(ctx, `1`, `web`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, access := range []string{token.Access, t0.Access} {
+		_, _, e = m.Get(ctx, access)
+		if e != cryptoer.ErrNotExistsToken {
+			t.Fatal(`not revoked`, e)
+		}
+	}
+	token, e = m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(`new session revoked`, e)
+	}
+}
+func TestEncryptedStore(t *testing.T) {
+	ctx := context.Background()
+	newAEAD := func(key byte) cipher.AEAD {
+		block, e := aes.NewCipher(bytes.Repeat([]byte{key}, 32))
+		if e != nil {
+			t.Fatal(e)
+		}
+		aead, e := cipher.NewGCM(block)
+		if e != nil {
+			t.Fatal(e)
+		}
+		return aead
+	}
+	backend := store.NewMemory(100)
+	s1, e := sessionstore.NewEncryptedStore(backend, 1, map[uint64]cipher.AEAD{
+		1: newAEAD(1),
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	m := sessionstore.New(Coder{}, sessionstore.WithStore(s1))
+	t1, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: `secret name`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	t2, e := m.Put(ctx, `2`, `web`, &Session{ID: `2`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = backend.Range(ctx, ``, func(key string, value []byte) bool {
+		if bytes.Contains(value, []byte(`secret name`)) {
+			t.Fatal(`value not encrypted`)
+		}
+		return true
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	// rotate
+	s2, e := sessionstore.NewEncryptedStore(backend, 2, map[uint64]cipher.AEAD{
+		1: newAEAD(1),
+		2: newAEAD(2),
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	m = sessionstore.New(Coder{}, sessionstore.WithStore(s2))
+	e = m.Update(ctx, t1.Access, &Session{ID: `1`, Name: `new name`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, s, e := m.Get(ctx, t1.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if s.(*Session).Name != `new name` {
+		t.Fatal(`Name not equal`)
+	}
+
+	// swap records between users
+	key1 := strings.Join(strings.Split(t1.Access, `.`)[:2], `.`)
+	key2 := strings.Join(strings.Split(t2.Access, `.`)[:2], `.`)
+	b, e := backend.Get(ctx, key1)
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = backend.Put(ctx, key2, b, time.Now().Add(time.Hour))
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Get(ctx, t2.Access)
+	if e != store.ErrDecrypt {
+		t.Fatal(`swapped record accepted`, e)
+	}
+}
+func TestMemoryTokenHash(t *testing.T) {
+	ctx := context.Background()
+	backend := store.NewMemory(100)
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(backend),
+		sessionstore.WithTokenHash([]byte(`hash key`)),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	e = backend.Range(ctx, ``, func(key string, value []byte) bool {
+		if bytes.Contains(value, []byte(token.Access)) || bytes.Contains(value, []byte(token.Refresh)) {
+			t.Fatal(`token stored in cleartext`)
+		}
+		return true
+	})
+	if e != nil {
+		t.Fatal(e)
+	}
+	t0, _, e := m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	if t0.Access != token.Access || t0.Refresh != `` {
+		t.Fatal(`Get token not matched`)
+	}
+	t1, _, e := m.Refresh(ctx, token.Access, token.Refresh)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Get(ctx, t1.Access)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Refresh(ctx, t1.Access, token.Refresh)
+	if e != cryptoer.ErrRefreshTokenNotMatched {
+		t.Fatal(`not ErrRefreshTokenNotMatched`, e)
+	}
+}
+func TestIDGenerator(t *testing.T) {
+	ctx := context.Background()
+	var i int
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithIDGenerator(sessionstore.IDGeneratorFunc(func() (string, error) {
+			i++
+			return fmt.Sprint(`id`, i), nil
+		})),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	if strings.Split(token.Access, `.`)[2] != `id1` || strings.Split(token.Refresh, `.`)[2] != `id2` {
+		t.Fatal(`deterministic id not used`)
+	}
+
+	for _, generator := range []sessionstore.IDGenerator{
+		sessionstore.NewULID(),
+		sessionstore.NewUUIDv7(),
+	} {
+		first, e := generator.Generate()
+		if e != nil {
+			t.Fatal(e)
+		}
+		time.Sleep(time.Millisecond * 2)
+		second, e := generator.Generate()
+		if e != nil {
+			t.Fatal(e)
+		}
+		if first >= second {
+			t.Fatal(`id not sortable`, first, second)
+		}
+	}
+	id, e := sessionstore.NewUUIDv7().Generate()
+	if e != nil {
+		t.Fatal(e)
+	}
+	if len(id) != 36 || id[14] != '7' {
+		t.Fatal(`not UUIDv7`, id)
+	}
+}
+func TestMemoryOpaque(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithOpaque(true),
+	)
+	id := `opaque-user`
+	web, e := m.Put(ctx, id, `web`, &Session{ID: id, Name: `web`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString([]byte(id))
+	if strings.Contains(web.Access, encoded) || strings.Contains(web.Refresh, encoded) {
+		t.Fatal(`token contains user id`)
+	}
+	_, s, e := m.Get(ctx, web.Access)
+	if e != nil {
+		t.Fatal(e)
+	} else if s.(*Session).Name != `web` {
+		t.Fatal(`session not matched`)
+	}
+	web, _, e = m.Refresh(ctx, web.Access, web.Refresh)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, e = m.Put(ctx, id, `app`, &Session{ID: id, Name: `app`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	elements, e := m.List(ctx, id)
+	if e != nil {
+		t.Fatal(e)
+	} else if len(elements) != 2 {
+		t.Fatal(`list not matched`, len(elements))
+	}
+	elements, e = m.ListPlatform(ctx, id, `web`)
+	if e != nil {
+		t.Fatal(e)
+	} else if len(elements) != 1 || elements[0].Platform != `web` {
+		t.Fatal(`list platform not matched`)
+	}
+
+	// 每個平臺只保留一個 session
+	old := web
+	web, e = m.Put(ctx, id, `web`, &Session{ID: id, Name: `web`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Get(ctx, old.Access)
+	if e != cryptoer.ErrNotExistsToken {
+		t.Fatal(`old session not replaced`, e)
+	}
+
+	e = m.DeletePlatform(ctx, id, `web`)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Get(ctx, web.Access)
+	if e != cryptoer.ErrNotExistsToken {
+		t.Fatal(`platform not deleted`, e)
+	}
+	e = m.DeleteID(ctx, id)
+	if e != nil {
+		t.Fatal(e)
+	}
+	elements, e = m.List(ctx, id)
+	if e != nil {
+		t.Fatal(e)
+	} else if len(elements) != 0 {
+		t.Fatal(`id not deleted`)
+	}
+}
+
+type TypedCoder struct {
+}
+
+func (TypedCoder) Unmarshal(b []byte) (session *Session, e error) {
+	var result Session
+	e = json.Unmarshal(b, &result)
+	if e != nil {
+		return
+	}
+	session = &result
+	return
+}
+func (TypedCoder) Marshal(session *Session) (b []byte, e error) {
+	b, e = json.Marshal(session)
+	return
+}
+func TestTyped(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.NewTyped[Session](TypedCoder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: `typed`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, s, e := m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	} else if s.Name != `typed` {
+		t.Fatal(`session not matched`)
+	}
+	s, e = m.Modify(ctx, token.Access, func(s *Session) (*Session, error) {
+		s.Name = `modified`
+		return s, nil
+	})
+	if e != nil {
+		t.Fatal(e)
+	} else if s.Name != `modified` {
+		t.Fatal(`modify not matched`)
+	}
+	elements, e := m.List(ctx, `1`)
+	if e != nil {
+		t.Fatal(e)
+	} else if len(elements) != 1 || elements[0].Session.Name != `modified` {
+		t.Fatal(`list not matched`)
+	}
+
+	// 非泛型的 api 依然可用
+	_, v, e := m.Manager().Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	} else if v.(*Session).Name != `modified` {
+		t.Fatal(`untyped session not matched`)
+	}
+	e = m.Manager().Update(ctx, token.Access, Session{})
+	if e != sessionstore.ErrSessionType {
+		t.Fatal(`type not checked`, e)
+	}
+
+	untyped := sessionstore.Typed[Session](sessionstore.New(Coder{}))
+	token, e = untyped.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: `wrapped`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, s, e = untyped.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	} else if s.Name != `wrapped` {
+		t.Fatal(`wrapped session not matched`)
+	}
+}
+func TestCoders(t *testing.T) {
+	ctx := context.Background()
+	for _, coder := range []sessionstore.NamedCoder{
+		sessionstore.NewJSONCoder[Session](),
+		sessionstore.NewGobCoder[Session](),
+		sessionstore.NewGzipCoder(sessionstore.NewJSONCoder[Session]()),
+	} {
+		m := sessionstore.Typed[Session](sessionstore.New(coder))
+		token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: coder.Name()})
+		if e != nil {
+			t.Fatal(coder.Name(), e)
+		}
+		_, s, e := m.Get(ctx, token.Access)
+		if e != nil {
+			t.Fatal(coder.Name(), e)
+		} else if s.Name != coder.Name() {
+			t.Fatal(`session not matched`, coder.Name())
+		}
+	}
+
+	coder := sessionstore.NewProtoCoder[protoc_session.IndexItem]()
+	b, e := coder.Marshal(&protoc_session.IndexItem{Id: `proto`, Created: 1})
+	if e != nil {
+		t.Fatal(e)
+	}
+	v, e := coder.Unmarshal(b)
+	if e != nil {
+		t.Fatal(e)
+	} else if item := v.(*protoc_session.IndexItem); item.Id != `proto` || item.Created != 1 {
+		t.Fatal(`proto not matched`)
+	}
+
+	named, e := sessionstore.NewCoderFor[Session](`gob+gzip`)
+	if e != nil {
+		t.Fatal(e)
+	} else if named.Name() != `gob+gzip` {
+		t.Fatal(`name not matched`, named.Name())
+	}
+	b, e = named.Marshal(&Session{ID: `1`, Name: `registry`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	v, e = named.Unmarshal(b)
+	if e != nil {
+		t.Fatal(e)
+	} else if v.(*Session).Name != `registry` {
+		t.Fatal(`registry not matched`)
+	}
+	_, e = sessionstore.NewCoderFor[Session](`json+zstd`)
+	if e != sessionstore.ErrUnknownCoder {
+		t.Fatal(`unknown coder not reported`, e)
+	}
+
+	limited := sessionstore.NewGzipCoderLimit(sessionstore.NewJSONCoder[Session](), 64)
+	b, e = limited.Marshal(&Session{ID: `1`, Name: strings.Repeat(`a`, 64)})
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, e = limited.Unmarshal(b)
+	if e != sessionstore.ErrSessionTooLarge {
+		t.Fatal(`gzip limit not applied`, e)
+	}
+}
+func TestSchema(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemory(100)
+	old := sessionstore.New(sessionstore.NewJSONCoder[Session](),
+		sessionstore.WithStore(s),
+	)
+	token, e := old.Put(ctx, `1`, `web`, &Session{ID: `1`, Name: `old`})
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	upgrade := func(f func(s *Session)) sessionstore.UpgradeFunc {
+		return func(coder string, b []byte) ([]byte, error) {
+			if coder != `json` {
+				t.Fatal(`coder not recorded`, coder)
+			}
+			var s Session
+			e := json.Unmarshal(b, &s)
+			if e != nil {
+				return nil, e
+			}
+			f(&s)
+			return json.Marshal(&s)
+		}
+	}
+	schema := sessionstore.NewSchema(2).
+		Register(0, upgrade(func(s *Session) {
+			s.Name = strings.ToUpper(s.Name)
+		})).
+		Register(1, upgrade(func(s *Session) {
+			s.Name += `!`
+		}))
+	for _, writeBack := range []bool{false, true} {
+		m := sessionstore.New(sessionstore.NewJSONCoder[Session](),
+			sessionstore.WithStore(s),
+			sessionstore.WithSchema(schema, writeBack),
+		)
+		_, v, e := m.Get(ctx, token.Access)
+		if e != nil {
+			t.Fatal(e)
+		} else if v.(*Session).Name != `OLD!` {
+			t.Fatal(`not upgraded`, v.(*Session).Name)
+		}
+		_, _, b, e := m.GetRaw(ctx, token.Access)
+		if e != nil {
+			t.Fatal(e)
+		} else if bytes.Contains(b, []byte(`OLD!`)) != writeBack {
+			t.Fatal(`write back not matched`, writeBack, string(b))
+		}
+	}
+
+	// 舊版本的程序不能讀取新版本的數據
+	m := sessionstore.New(sessionstore.NewJSONCoder[Session](),
+		sessionstore.WithStore(s),
+		sessionstore.WithSchema(sessionstore.NewSchema(1), false),
+	)
+	_, _, e = m.Get(ctx, token.Access)
+	if e != sessionstore.ErrSchemaVersion {
+		t.Fatal(`newer version not reported`, e)
+	}
+
+	// 使用記錄的 Coder 解碼，寫回時使用當前 Coder 重新編碼
+	token, e = old.Put(ctx, `2`, `web`, &Session{ID: `2`, Name: `old`})
+	if e != nil {
+		t.Fatal(e)
+	}
+	for _, writeBack := range []bool{false, true} {
+		m = sessionstore.New(sessionstore.NewGobCoder[Session](),
+			sessionstore.WithStore(s),
+			sessionstore.WithSchema(schema, writeBack),
+		)
+		_, v, e := m.Get(ctx, token.Access)
+		if e != nil {
+			t.Fatal(e)
+		} else if v.(*Session).Name != `OLD!` {
+			t.Fatal(`not upgraded`, v.(*Session).Name)
+		}
+		_, _, b, e := m.GetRaw(ctx, token.Access)
+		if e != nil {
+			t.Fatal(e)
+		} else if json.Valid(b) == writeBack {
+			t.Fatal(`coder not converted`, writeBack)
+		}
+	}
+	// 不能創建 session 的 Coder 無法解碼其它 Coder 的數據
+	m = sessionstore.New(namedCoder{},
+		sessionstore.WithStore(s),
+	)
+	_, _, e = m.Get(ctx, token.Access)
+	if e != sessionstore.ErrCoderNotMatched {
+		t.Fatal(`coder not checked`, e)
+	}
+}
+
+type namedCoder struct {
+	Coder
+}
+
+func (namedCoder) Name() string {
+	return `custom`
+}
+func TestMetadata(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithLastSeen(time.Second),
+	)
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`},
+		sessionstore.WithClientIP(`192.168.1.2`),
+		sessionstore.WithUserAgent(`test-agent`),
+		sessionstore.WithDevice(`laptop`),
+	)
+	if e != nil {
+		t.Fatal(e)
+	}
+	md := token.Metadata
+	if md == nil || md.IP != `192.168.1.2` || md.UserAgent != `test-agent` || md.Device != `laptop` ||
+		md.Created.IsZero() || !md.Refreshed.IsZero() {
+		t.Fatal(`put metadata not matched`, md)
+	}
+	created := md.Created
+
+	time.Sleep(time.Second * 2)
+	got, _, e := m.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	} else if !got.Metadata.LastSeen.After(created) {
+		t.Fatal(`last seen not updated`, got.Metadata.LastSeen, created)
+	}
+
+	token, _, e = m.Refresh(ctx, token.Access, token.Refresh,
+		sessionstore.WithClientIP(`10.0.0.1`),
+	)
+	if e != nil {
+		t.Fatal(e)
+	}
+	elements, e := m.List(ctx, `1`)
+	if e != nil {
+		t.Fatal(e)
+	} else if len(elements) != 1 {
+		t.Fatal(`list not matched`)
+	}
+	md = elements[0].Token.Metadata
+	if md.IP != `10.0.0.1` || md.Device != `laptop` || !md.Created.Equal(created) || md.Refreshed.IsZero() {
+		t.Fatal(`refresh metadata not matched`, md)
+	}
+
+	block, e := aes.NewCipher(make([]byte, 32))
+	if e != nil {
+		t.Fatal(e)
+	}
+	aead, e := cipher.NewGCM(block)
+	if e != nil {
+		t.Fatal(e)
+	}
+	stateless := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithStateless(aead),
+	)
+	token, e = stateless.Put(ctx, `1`, `web`, &Session{ID: `1`}, sessionstore.WithDevice(`phone`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	got, _, e = stateless.Get(ctx, token.Access)
+	if e != nil {
+		t.Fatal(e)
+	} else if got.Metadata.Device != `phone` {
+		t.Fatal(`stateless metadata not matched`)
+	}
+}
+func TestBinding(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithBinding(sessionstore.Bindings(
+			sessionstore.BindIPPrefix(24, 64),
+			sessionstore.BindUserAgent(),
+		), nil),
+	)
+	client := func(ip string) []sessionstore.MetadataOption {
+		return []sessionstore.MetadataOption{
+			sessionstore.WithClientIP(ip),
+			sessionstore.WithUserAgent(`test-agent`),
+		}
+	}
+	token, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`}, client(`192.168.1.2`)...)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Get(ctx, token.Access, client(`192.168.1.200`)...)
+	if e != nil {
+		t.Fatal(e)
+	}
+	_, _, e = m.Get(ctx, token.Access, client(`192.168.2.1`)...)
+	if e != cryptoer.ErrBindingNotMatched {
+		t.Fatal(`other network accepted`, e)
+	}
+	_, _, e = m.Get(ctx, token.Access, sessionstore.WithClientIP(`192.168.1.2`))
+	if e != cryptoer.ErrBindingNotMatched {
+		t.Fatal(`other user agent accepted`, e)
+	}
+	_, _, e = m.Refresh(ctx, token.Access, token.Access, client(`10.0.0.1`)...)
+	if e != cryptoer.ErrRefreshTokenNotMatched {
+		t.Fatal(`binding checked before refresh token`, e)
+	}
+	_, _, e = m.Refresh(ctx, token.Access, token.Refresh, client(`10.0.0.1`)...)
+	if e != cryptoer.ErrBindingNotMatched {
+		t.Fatal(`refresh from other network accepted`, e)
+	}
+	token, _, e = m.Refresh(ctx, token.Access, token.Refresh, client(`192.168.1.3`)...)
+	if e != nil {
+		t.Fatal(e)
+	}
+
+	var decision sessionstore.BindingDecision
+	m = sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithBinding(sessionstore.BindValue(), func(ctx context.Context, metadata *sessionstore.Metadata, client *sessionstore.Client) sessionstore.BindingDecision {
+			if client.Value == `` {
+				t.Fatal(`client value not provided`)
+			}
+			return decision
+		}),
+	)
+	token, e = m.Put(ctx, `1`, `web`, &Session{ID: `1`}, sessionstore.WithBindingValue(`cert-a`))
+	if e != nil {
+		t.Fatal(e)
+	}
+	decision = sessionstore.BindingAllow
+	_, _, e = m.Get(ctx, token.Access, sessionstore.WithBindingValue(`cert-b`))
+	if e != nil {
+		t.Fatal(`policy allow ignored`, e)
+	}
+	decision = sessionstore.BindingReauth
+	_, _, e = m.Refresh(ctx, token.Access, token.Access, sessionstore.WithBindingValue(`cert-b`))
+	if e != cryptoer.ErrRefreshTokenNotMatched {
+		t.Fatal(`binding checked before refresh token`, e)
+	}
+	_, _, e = m.Get(ctx, token.Access, sessionstore.WithBindingValue(`cert-a`))
+	if e != nil {
+		t.Fatal(`session deleted without refresh token`, e)
+	}
+	_, _, e = m.Get(ctx, token.Access, sessionstore.WithBindingValue(`cert-b`))
+	if e != cryptoer.ErrReauthRequired {
+		t.Fatal(`policy reauth ignored`, e)
+	}
+	_, _, e = m.Get(ctx, token.Access, sessionstore.WithBindingValue(`cert-a`))
+	if e != cryptoer.ErrNotExistsToken {
+		t.Fatal(`session not deleted`, e)
+	}
+}
+func TestMemoryMultipleConcurrent(t *testing.T) {
+	ctx := context.Background()
+	m := sessionstore.New(Coder{},
+		sessionstore.WithStore(store.NewMemory(100)),
+		sessionstore.WithMultiple(3, sessionstore.EvictReject),
+		sessionstore.WithRetry(100),
+	)
+	var (
+		wait     sync.WaitGroup
+		mutex    sync.Mutex
+		accepted int
+	)
+	for i := 0; i < 8; i++ {
+		wait.Add(1)
+		go func() {
+			defer wait.Done()
+			_, e := m.Put(ctx, `1`, `web`, &Session{ID: `1`})
+			if e == nil {
+				mutex.Lock()
+				accepted++
+				mutex.Unlock()
+			} else if e != cryptoer.ErrTooManySessions {
+				t.Error(e)
+			}
+		}()
+	}
+	wait.Wait()
+	elements, e := m.ListPlatform(ctx, `1`, `web`)
+	if e != nil {
+		t.Fatal(e)
+	} else if accepted != 3 || len(elements) != 3 {
+		t.Fatal(`max sessions exceeded`, accepted, len(elements))
+	}
+}
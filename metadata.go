@@ -0,0 +1,117 @@
+package sessionstore
+
+import (
+	"time"
+
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
+)
+
+// 由 Manager 維護的 session 元數據
+type Metadata struct {
+	// 創建時間
+	Created time.Time
+	// 最後刷新時間，如果沒有刷新過爲零值
+	Refreshed time.Time
+	// 最後活動時間，只在 寫入 session 和 啓用 WithLastSeen 或 WithIdle 時更新，無狀態模式下爲零值
+	LastSeen time.Time
+	// 客戶端 ip
+	IP string
+	// 客戶端 User-Agent
+	UserAgent string
+	// 設備名稱
+	Device string
+}
+
+func newMetadata(md *protoc_session.Metadata, active int64) *Metadata {
+	if md == nil {
+		return nil
+	}
+	metadata := &Metadata{
+		Created:   time.Unix(md.Created, 0),
+		IP:        md.Ip,
+		UserAgent: md.UserAgent,
+		Device:    md.Device,
+	}
+	if md.Refreshed != 0 {
+		metadata.Refreshed = time.Unix(md.Refreshed, 0)
+	}
+	if active != 0 {
+		metadata.LastSeen = time.Unix(active, 0)
+	}
+	return metadata
+}
+
+// 客戶端信息
+type metadataOptions struct {
+	ip        string
+	userAgent string
+	device    string
+	// 調用者提供的綁定值
+	value string
+}
+
+// 在 Put 和 Refresh 時設置 session 元數據，在 Get 時提供客戶端信息以檢查 session 綁定
+type MetadataOption interface {
+	apply(*metadataOptions)
+}
+type funcMetadataOption struct {
+	f func(*metadataOptions)
+}
+
+func (fdo *funcMetadataOption) apply(do *metadataOptions) {
+	fdo.f(do)
+}
+func newFuncMetadataOption(f func(*metadataOptions)) *funcMetadataOption {
+	return &funcMetadataOption{
+		f: f,
+	}
+}
+
+// 設置客戶端 ip，Refresh 時如果爲空字符串則保留原值
+func WithClientIP(ip string) MetadataOption {
+	return newFuncMetadataOption(func(o *metadataOptions) {
+		o.ip = ip
+	})
+}
+
+// 設置客戶端 User-Agent，Refresh 時如果爲空字符串則保留原值
+func WithUserAgent(userAgent string) MetadataOption {
+	return newFuncMetadataOption(func(o *metadataOptions) {
+		o.userAgent = userAgent
+	})
+}
+
+// 設置設備名稱，Refresh 時如果爲空字符串則保留原值
+func WithDevice(device string) MetadataOption {
+	return newFuncMetadataOption(func(o *metadataOptions) {
+		o.device = device
+	})
+}
+
+func newMetadataOptions(opt []MetadataOption) (opts metadataOptions) {
+	for _, o := range opt {
+		o.apply(&opts)
+	}
+	return
+}
+
+// 將 opt 應用到 md，如果 md 爲 nil 則創建新的元數據
+func (m *Manager) applyMetadata(md *protoc_session.Metadata, now time.Time, opt []MetadataOption) *protoc_session.Metadata {
+	if md == nil {
+		md = &protoc_session.Metadata{
+			Created: now.Unix(),
+			Binding: m.fingerprint(opt),
+		}
+	}
+	opts := newMetadataOptions(opt)
+	if opts.ip != `` {
+		md.Ip = opts.ip
+	}
+	if opts.userAgent != `` {
+		md.UserAgent = opts.userAgent
+	}
+	if opts.device != `` {
+		md.Device = opts.device
+	}
+	return md
+}
@@ -0,0 +1,258 @@
+package sessionstore
+
+import (
+	"context"
+	"encoding/base64"
+	"strings"
+	"time"
+
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
+	"google.golang.org/protobuf/proto"
+)
+
+// 多 session 模式下 session 數量超過限制時的處理策略
+type EvictPolicy int
+
+const (
+	// 刪除最早創建的 session
+	EvictOldest EvictPolicy = iota
+	// 拒絕創建新的 session 並返回 cryptoer.ErrTooManySessions
+	EvictReject
+)
+
+// 返回用戶在平臺上的 key 前綴 RawURLBase64(id).RawURLBase64(platform)
+func encodeKey(id, platform string) string {
+	return base64.RawURLEncoding.EncodeToString(StringToBytes(id)) + `.` +
+		base64.RawURLEncoding.EncodeToString(StringToBytes(platform))
+}
+
+// 從存儲 key 中解析出 用戶 id 和 平臺
+func decodeKey(key string) (id, platform string, e error) {
+	strs := strings.SplitN(key, `.`, 3)
+	if len(strs) < 2 {
+		e = cryptoer.ErrInvalidToken
+		return
+	}
+	b, e := base64.RawURLEncoding.DecodeString(strs[0])
+	if e != nil {
+		return
+	}
+	id = string(b)
+	b, e = base64.RawURLEncoding.DecodeString(strs[1])
+	if e != nil {
+		return
+	}
+	platform = string(b)
+	return
+}
+
+// 返回 session 是否記錄在 用戶平臺 的索引中
+func (m *Manager) indexed() bool {
+	return m.opts.multiple || m.opts.opaque
+}
+
+// 返回索引項 id 對應的 session 存儲 key，opaque 模式下 key 就是隨機的 session 句柄
+func (m *Manager) sessionKey(prefix, id string) string {
+	if m.opts.opaque {
+		return id
+	}
+	return prefix + `.` + id
+}
+
+// 返回 session 所屬的 用戶 id 和 平臺
+func (m *Manager) ownerOf(key string, raw *protoc_session.Raw) (id, platform string, e error) {
+	if m.opts.opaque {
+		id = raw.Id
+		platform = raw.Platform
+		return
+	}
+	id, platform, e = decodeKey(key)
+	return
+}
+
+// 返回 session 在索引中的 索引key 和 索引項 id
+func (m *Manager) indexOf(key string, raw *protoc_session.Raw) (prefix, id string, ok bool) {
+	if m.opts.opaque {
+		prefix = encodeKey(raw.Id, raw.Platform)
+		id = key
+		ok = true
+		return
+	}
+	prefix, id, ok = splitMultipleKey(key)
+	return
+}
+
+// 返回 prefix 下記錄的 session 索引，已經失效的 session 會被過濾掉
+func (m *Manager) getIndex(ctx context.Context, prefix string) (index *protoc_session.Index, e error) {
+	index, _, e = m.readIndex(ctx, prefix, false)
+	return
+}
+
+// 返回 prefix 下記錄的 session 索引 和 存儲中的原始數據，索引不存在時 old 爲 nil，
+// 準備 CompareAndSwap 時 latest 爲 true 從寫入節點讀取最新數據
+func (m *Manager) readIndex(ctx context.Context, prefix string, latest bool) (index *protoc_session.Index, old []byte, e error) {
+	if latest {
+		old, e = getLatest(ctx, m.opts.store, prefix)
+	} else {
+		old, e = m.opts.store.Get(ctx, prefix)
+	}
+	if e != nil {
+		return
+	}
+	index = &protoc_session.Index{}
+	if old == nil {
+		return
+	}
+	e = proto.Unmarshal(old, index)
+	if e != nil {
+		return
+	}
+	items := index.Items[:0]
+	for _, item := range index.Items {
+		var b []byte
+		b, e = m.opts.store.Get(ctx, m.sessionKey(prefix, item.Id))
+		if e != nil {
+			return
+		} else if b != nil {
+			items = append(items, item)
+		}
+	}
+	index.Items = items
+	return
+}
+
+// 讀取 prefix 下的索引 交給 f 修改，之後以 CompareAndSwap 寫回存儲，發生衝突時重新讀取並再次調用 f。
+// f 返回索引的有效期，如果修改後索引爲空則刪除索引
+func (m *Manager) modifyIndex(ctx context.Context, prefix string, f func(index *protoc_session.Index) (deadline time.Time, e error)) (e error) {
+	var (
+		index    *protoc_session.Index
+		old, b   []byte
+		deadline time.Time
+		swapped  bool
+	)
+	for i := 0; ; i++ {
+		index, old, e = m.readIndex(ctx, prefix, true)
+		if e != nil {
+			return
+		}
+		deadline, e = f(index)
+		if e != nil {
+			if e == errNotModified {
+				e = nil
+			}
+			return
+		}
+		if len(index.Items) == 0 {
+			if old == nil {
+				return
+			}
+			// 已經過去的有效期會刪除索引
+			b = nil
+			deadline = time.Now()
+		} else {
+			b, e = proto.Marshal(index)
+			if e != nil {
+				return
+			}
+		}
+		swapped, e = m.opts.store.CompareAndSwap(ctx, prefix, old, b, deadline)
+		if e != nil || swapped {
+			return
+		} else if i >= m.opts.retry {
+			e = cryptoer.ErrConflict
+			return
+		}
+	}
+}
+
+// 使用 CompareAndSwap 清空 prefix 的索引，然後刪除被移出索引的 session
+func (m *Manager) clearIndex(ctx context.Context, prefix string) (e error) {
+	var items []*protoc_session.IndexItem
+	e = m.modifyIndex(ctx, prefix, func(index *protoc_session.Index) (deadline time.Time, e error) {
+		items = index.Items
+		index.Items = nil
+		return
+	})
+	if e != nil {
+		return
+	}
+	for _, item := range items {
+		e = m.opts.store.Del(ctx, m.sessionKey(prefix, item.Id))
+		if e != nil {
+			return
+		}
+	}
+	return
+}
+
+// 爲 prefix 分配一個新的 session key
+func (m *Manager) newIndexedKey(prefix string) (key, id string, e error) {
+	id, e = m.generateID()
+	if e != nil {
+		return
+	}
+	key = m.sessionKey(prefix, id)
+	return
+}
+
+// 將已經寫入存儲的 session 加入 prefix 下的索引，並依據策略淘汰多餘的 session
+func (m *Manager) addIndex(ctx context.Context, prefix, id string, now, deadline time.Time) (e error) {
+	e = m.modifyIndex(ctx, prefix, func(index *protoc_session.Index) (time.Time, error) {
+		if !m.opts.multiple {
+			// 每個平臺只允許一個 session，新的 session 替換舊的
+			for _, item := range index.Items {
+				e := m.opts.store.Del(ctx, m.sessionKey(prefix, item.Id))
+				if e != nil {
+					return deadline, e
+				}
+			}
+			index.Items = index.Items[:0]
+		} else if m.opts.max > 0 {
+			if len(index.Items) >= m.opts.max && m.opts.evict == EvictReject {
+				return deadline, cryptoer.ErrTooManySessions
+			}
+			for len(index.Items) >= m.opts.max {
+				e := m.opts.store.Del(ctx, m.sessionKey(prefix, index.Items[0].Id))
+				if e != nil {
+					return deadline, e
+				}
+				index.Items = index.Items[1:]
+			}
+		}
+		index.Items = append(index.Items, &protoc_session.IndexItem{
+			Id:      id,
+			Created: now.Unix(),
+		})
+		return deadline, nil
+	})
+	return
+}
+
+// 刷新 session 後延長索引的有效期，索引的有效期總是不短於其記錄的 session
+func (m *Manager) touchIndex(ctx context.Context, prefix, id string, deadline time.Time) (e error) {
+	e = m.modifyIndex(ctx, prefix, func(index *protoc_session.Index) (time.Time, error) {
+		for _, item := range index.Items {
+			if item.Id == id {
+				return deadline, nil
+			}
+		}
+		index.Items = append(index.Items, &protoc_session.IndexItem{
+			Id:      id,
+			Created: time.Now().Unix(),
+		})
+		return deadline, nil
+	})
+	return
+}
+
+func splitMultipleKey(key string) (prefix, id string, ok bool) {
+	i := strings.LastIndex(key, `.`)
+	if i == -1 || strings.Index(key, `.`) == i {
+		return
+	}
+	prefix = key[:i]
+	id = key[i+1:]
+	ok = true
+	return
+}
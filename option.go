@@ -1,23 +1,34 @@
 package sessionstore
 
 import (
+	"crypto/cipher"
 	"time"
 
 	"github.com/powerpuffpenguin/sessionstore/cryptoer"
 )
 
 var defaultOptions = options{
-	method:   cryptoer.SigningMethodHMD5,
-	key:      []byte(`cerberu is an idea`),
-	access:   time.Hour,
-	refresh:  time.Hour * 12 * 3,
-	deadline: time.Hour * 24 * 30,
+	method:    cryptoer.SigningMethodHMD5,
+	key:       []byte(`cerberu is an idea`),
+	access:    time.Hour,
+	refresh:   time.Hour * 12 * 3,
+	deadline:  time.Hour * 24 * 30,
+	retry:     3,
+	generator: NewRandomID(16),
 }
 
 type options struct {
 	// 簽名算法
 	method cryptoer.SigningMethod
 	key    []byte
+	// 驗證簽名的密鑰，如果爲 nil 則使用 key
+	verify []byte
+	// 如果不爲 nil 則使用密鑰環簽名和驗證，並忽略 key
+	keys *cryptoer.KeyRing
+	// 生成 token 唯一標識
+	generator IDGenerator
+	// 是否使用 JWT 格式的 token
+	jwt bool
 	// token 有效期
 	access  time.Duration
 	refresh time.Duration
@@ -26,6 +37,45 @@ type options struct {
 
 	// 存儲後端
 	store Store
+	// 如果不爲 nil 則使用無狀態模式，session 加密後保存在 token 中
+	stateless cipher.AEAD
+	// 是否使用不包含用戶信息的隨機 session 句柄作爲 token
+	opaque bool
+	// 如果不爲 nil 則存儲中只保存 token 以此爲密鑰的 HMAC
+	hash []byte
+	// 如果不爲 nil 則記錄 session 版本，並在讀取時升級舊版本的數據
+	schema *Schema
+	// 是否將升級後的數據寫回存儲
+	writeBack bool
+	// 寫入 session 發生衝突時的重試次數
+	retry int
+
+	// 是否允許同一用戶在同一平臺同時存在多個 session
+	multiple bool
+	// 每個平臺最多允許的 session 數量，如果爲 0 則不限制
+	max int
+	// 超過 max 時的處理策略
+	evict EvictPolicy
+
+	// 記住最近多少個已經輪換的 refresh token，如果爲 0 則不檢測 refresh token 重用
+	reuse int
+	// 檢測到 refresh token 重用時的回調
+	reused ReusedFunc
+
+	// 刷新後 上一組 token 仍然有效的寬限期
+	grace time.Duration
+
+	// 如果不爲 0，Get 時 access 有效期已經過去的比例達到此值則延長有效期
+	sliding float64
+	// 如果不爲 0，session 超過此時間沒有活動則失效
+	idle time.Duration
+	// 如果不爲 0，Get 時每隔此時間最多記錄一次最後活動時間
+	seen time.Duration
+
+	// 如果不爲 nil 則 session 綁定到創建它的客戶端
+	binding Binding
+	// 客戶端指紋不匹配時的處理策略，如果爲 nil 則拒絕
+	policy BindingPolicy
 }
 type Option interface {
 	apply(*options)
@@ -52,6 +102,32 @@ func WithKey(key []byte) Option {
 		o.key = key
 	})
 }
+
+// 設置驗證簽名的密鑰，用於非對稱簽名算法，key 爲私鑰 verify 爲公鑰。
+// 只驗證 token 的服務可以只設置 verify
+func WithVerifyKey(verify []byte) Option {
+	return newFuncOption(func(o *options) {
+		o.verify = verify
+	})
+}
+
+// 使用密鑰環簽名和驗證 token，密鑰 id 會被寫入 token 以便在不使現有 token 失效的情況下輪換密鑰
+func WithKeys(keys *cryptoer.KeyRing) Option {
+	return newFuncOption(func(o *options) {
+		o.keys = keys
+	})
+}
+
+// 使用 JWS compact 格式的 JWT 作爲 token，
+// header 中的 alg 爲 SigningMethod.Alg()，使用密鑰環時 kid 爲密鑰 id，
+// claims 包含 sub(用戶 id) platform sid(多 session 模式) jti iat exp。
+// token 仍然和存儲後端關聯，所以依然可以被刪除。
+// JWT 的 exp 在簽發後不能改變，所以此模式下 WithSliding 被忽略
+func WithJWT(jwt bool) Option {
+	return newFuncOption(func(o *options) {
+		o.jwt = jwt
+	})
+}
 func WithAccess(access time.Duration) Option {
 	return newFuncOption(func(o *options) {
 		o.access = access
@@ -75,3 +151,144 @@ func WithStore(store Store) Option {
 		o.store = store
 	})
 }
+func WithRetry(retry int) Option {
+	return newFuncOption(func(o *options) {
+		if retry < 0 {
+			retry = 0
+		}
+		o.retry = retry
+	})
+}
+
+// 允許同一用戶在同一平臺同時存在多個 session，每次 Put 都會創建一個新的 session。
+// max 爲每個平臺最多允許的 session 數量，如果 < 1 則不限制，
+// 超過 max 時依據 evict 刪除最早的 session 或拒絕創建新的 session
+func WithMultiple(max int, evict EvictPolicy) Option {
+	return newFuncOption(func(o *options) {
+		if max < 1 {
+			max = 0
+		}
+		o.multiple = true
+		o.max = max
+		o.evict = evict
+	})
+}
+
+// 記住最近 keep 個已經輪換的 refresh token，當它們被再次用於 Refresh 時，
+// 認爲 token 可能已經被盜用，刪除整個 session 並返回 cryptoer.ErrRefreshTokenReused。
+// 如果 f 不爲 nil 會在刪除 session 後調用它，通常用於發出告警
+func WithRefreshReuse(keep int, f ReusedFunc) Option {
+	return newFuncOption(func(o *options) {
+		if keep < 0 {
+			keep = 0
+		}
+		o.reuse = keep
+		o.reused = f
+	})
+}
+
+// 設置刷新寬限期，Refresh 後的 grace 時間內上一組 token 仍然有效，
+// 使用上一組 token 再次 Refresh 會返回相同的新 token 而非錯誤
+func WithRefreshGrace(grace time.Duration) Option {
+	return newFuncOption(func(o *options) {
+		if grace < 0 {
+			grace = 0
+		}
+		o.grace = grace
+	})
+}
+
+// 啓用滑動有效期，Get 成功時如果 access 有效期已經過去了 fraction 比例，
+// 則將 access 和 refresh 有效期從當前時間重新計算(不會超過 WithDeadline)。
+// fraction 取值 (0,1]，較大的值可以減少寫入存儲的次數，如果不在範圍內則使用 0.5。
+// JWT 模式下 token 的 exp 不會隨之改變，所以和 WithJWT 同時使用時滑動有效期被禁用
+func WithSliding(fraction float64) Option {
+	return newFuncOption(func(o *options) {
+		if fraction <= 0 || fraction > 1 {
+			fraction = 0.5
+		}
+		o.sliding = fraction
+	})
+}
+
+// 設置閒置超時，session 超過 idle 時間沒有活動(Get/Refresh/Update)則被刪除，
+// 此時返回 cryptoer.ErrIdleTimeout。爲了減少寫入，活動時間的精度爲 idle/10
+func WithIdle(idle time.Duration) Option {
+	return newFuncOption(func(o *options) {
+		if idle < 0 {
+			idle = 0
+		}
+		o.idle = idle
+	})
+}
+
+// Get 時記錄 session 的最後活動時間(Metadata.LastSeen)，爲了減少寫入每隔 interval 最多記錄一次
+func WithLastSeen(interval time.Duration) Option {
+	return newFuncOption(func(o *options) {
+		o.seen = interval
+	})
+}
+
+// 將 session 綁定到創建它的客戶端，Put 時使用 binding 計算並記錄客戶端指紋，
+// Get 和 Refresh 時使用 MetadataOption 提供的客戶端信息計算指紋並比較，
+// 不匹配時調用 policy 決定 允許 拒絕 或 要求重新登入，如果 policy 爲 nil 則返回 cryptoer.ErrBindingNotMatched
+func WithBinding(binding Binding, policy BindingPolicy) Option {
+	return newFuncOption(func(o *options) {
+		o.binding = binding
+		o.policy = policy
+	})
+}
+
+// 啓用無狀態模式，Coder 編碼的 session 使用 aead 加密並認證後保存在 access token 中，
+// Get 在本地解密而不需要讀取 session 存儲，aead 可以是 AES-GCM 或 XChaCha20-Poly1305。
+// 此模式下 Store 只用於保存 用戶/平臺 的撤銷時間：
+// Delete 撤銷 token 所屬平臺，DeletePlatform 和 DeleteID 撤銷在此之前簽發的所有 token，
+// Update Modify List 不被支持並返回 cryptoer.ErrNotSupported
+func WithStateless(aead cipher.AEAD) Option {
+	return newFuncOption(func(o *options) {
+		o.stateless = aead
+	})
+}
+
+// 存儲中只保存 access 和 refresh token 以 key 爲密鑰的 HMAC-SHA256，而非 token 本身，
+// 這樣洩漏的存儲快照不包含可用的 token。
+// 此時從存儲讀取的 Token 中 Refresh 爲空字符串(Access 只在由調用者提供時返回)，
+// 新的 token 只在 Put 和 Refresh 時返回給調用者，
+// 寬限期內使用上一組 token 再次 Refresh 將返回 cryptoer.ErrNotSupported
+func WithTokenHash(key []byte) Option {
+	return newFuncOption(func(o *options) {
+		o.hash = key
+	})
+}
+
+// 設置 session 結構的版本，存儲的 session 會記錄 Coder 名稱和版本，
+// 讀取舊版本的數據時使用 schema 中註冊的函數逐級升級到當前版本，再使用記錄的 Coder 解碼。
+// 如果 writeBack 爲 true，Get 會將升級並使用當前 Coder 重新編碼後的數據寫回存儲，
+// 否則只在 session 被修改時才以新版本寫入，在此之前每次 Get 都會重新執行升級。
+// 無狀態模式下的 session 不記錄版本
+func WithSchema(schema *Schema, writeBack bool) Option {
+	return newFuncOption(func(o *options) {
+		o.schema = schema
+		o.writeBack = writeBack
+	})
+}
+
+// 使用不透明的 token，token 爲 handle.id.sign，handle 是隨機生成的 session 存儲 key，
+// 所以 token 中不包含 用戶 id 和 平臺。
+// session 記錄自己的 用戶 id 和 平臺，並在 RawURLBase64(id).RawURLBase64(platform) 下保存句柄索引，
+// 以支持 DeleteID DeletePlatform List ListPlatform。此模式下 WithJWT 被忽略
+func WithOpaque(opaque bool) Option {
+	return newFuncOption(func(o *options) {
+		o.opaque = opaque
+	})
+}
+
+// 設置生成 token 唯一標識的算法，默認爲 NewRandomID(16)
+func WithIDGenerator(generator IDGenerator) Option {
+	return newFuncOption(func(o *options) {
+		if generator == nil {
+			generator = NewRandomID(16)
+		}
+		o.generator = generator
+	})
+}
@@ -0,0 +1,72 @@
+package sessionstore
+
+import (
+	"context"
+	"errors"
+
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
+)
+
+// 檢測到已經輪換的 refresh token 被再次使用時的回調，此時 session 已經被刪除
+type ReusedFunc func(ctx context.Context, id, platform string)
+
+// 在 modifyRaw 中標記 refresh token 重用，session 在事務之外刪除
+var errRefreshTokenReused = errors.New(`refresh token reused in modify`)
+
+// 記錄被輪換掉的 refresh token，只保留最近的 keep 個
+func appendRotated(rotated []string, refresh string, keep int) []string {
+	rotated = append(rotated, refresh)
+	if len(rotated) > keep {
+		rotated = rotated[len(rotated)-keep:]
+	}
+	return rotated
+}
+func (m *Manager) isRotated(rotated []string, refresh string) bool {
+	for _, str := range rotated {
+		if m.matchToken(str, refresh) {
+			return true
+		}
+	}
+	return false
+}
+
+// 舊的 access 已經找不到 session 時，檢查 refresh 是否爲 session 已經輪換掉的 refresh token
+func (m *Manager) checkReused(ctx context.Context, access, refresh string) (e error) {
+	key, e := m.verifyKey(access)
+	if e != nil {
+		return
+	}
+	b, e := m.opts.store.Get(ctx, key)
+	if e != nil {
+		return
+	} else if b == nil {
+		e = cryptoer.ErrNotExistsToken
+		return
+	}
+	raw, e := unmarshalRaw(b)
+	if e != nil {
+		return
+	} else if !m.isRotated(raw.Rotated, refresh) {
+		e = cryptoer.ErrNotExistsToken
+		return
+	}
+	e = m.revokeReused(ctx, key, raw)
+	return
+}
+
+// 刪除 refresh token 被重用的 session 並通知回調
+func (m *Manager) revokeReused(ctx context.Context, key string, raw *protoc_session.Raw) (e error) {
+	e = m.opts.store.Del(ctx, key)
+	if e != nil {
+		return
+	}
+	if m.opts.reused != nil {
+		id, platform, err := m.ownerOf(key, raw)
+		if err == nil {
+			m.opts.reused(ctx, id, platform)
+		}
+	}
+	e = cryptoer.ErrRefreshTokenReused
+	return
+}
@@ -0,0 +1,162 @@
+package sessionstore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
+)
+
+// 將 Coder 編碼的 version 版本 session 數據升級到 version+1 版本，coder 爲編碼數據的 Coder 名稱
+type UpgradeFunc func(coder string, b []byte) (data []byte, e error)
+
+// session 結構的版本，記錄了從舊版本逐級升級到當前版本的函數。
+// 沒有記錄版本的數據(在使用 Schema 之前寫入)版本爲 0
+type Schema struct {
+	version  uint32
+	upgrades map[uint32]UpgradeFunc
+	rw       sync.RWMutex
+}
+
+// 創建當前版本爲 version 的 Schema
+func NewSchema(version uint32) *Schema {
+	return &Schema{
+		version:  version,
+		upgrades: make(map[uint32]UpgradeFunc),
+	}
+}
+
+// 返回當前版本
+func (s *Schema) Version() uint32 {
+	return s.version
+}
+
+// 註冊從 from 版本升級到 from+1 版本的函數，如果 f 爲 nil 表示兩個版本的數據兼容不需要轉換
+func (s *Schema) Register(from uint32, f UpgradeFunc) *Schema {
+	s.rw.Lock()
+	s.upgrades[from] = f
+	s.rw.Unlock()
+	return s
+}
+
+// 將 coder 編碼的 version 版本數據逐級升級到當前版本
+func (s *Schema) Upgrade(coder string, version uint32, b []byte) (data []byte, e error) {
+	if version > s.version {
+		e = ErrSchemaVersion
+		return
+	}
+	s.rw.RLock()
+	defer s.rw.RUnlock()
+	data = b
+	for ; version < s.version; version++ {
+		f, ok := s.upgrades[version]
+		if !ok {
+			e = ErrSchemaVersion
+			return
+		} else if f != nil {
+			data, e = f(coder, data)
+			if e != nil {
+				return
+			}
+		}
+	}
+	return
+}
+
+// 返回 Coder 的註冊名稱，如果 Coder 沒有名稱返回空字符串
+func (m *Manager) coderName() string {
+	if coder, ok := m.coder.(interface{ Name() string }); ok {
+		return coder.Name()
+	}
+	return ``
+}
+
+// 返回寫入新數據時使用的 session 版本
+func (m *Manager) schemaVersion() uint32 {
+	if m.opts.schema == nil {
+		return 0
+	}
+	return m.opts.schema.version
+}
+
+// 編碼 session 並記錄 Coder 名稱和版本
+func (m *Manager) marshalSession(raw *protoc_session.Raw, session interface{}) (e error) {
+	b, e := m.coder.Marshal(session)
+	if e != nil {
+		return
+	}
+	raw.Data = b
+	raw.Coder = m.coderName()
+	raw.Version = m.schemaVersion()
+	return
+}
+
+// 返回解碼 name 編碼數據的 Coder，如果 name 不是當前 Coder 則從註冊表中創建，
+// 此時當前 Coder 需要實現 SessionFactory
+func (m *Manager) decoder(name string) (coder Coder, converted bool, e error) {
+	current := m.coderName()
+	if name == `` || current == `` || name == current {
+		coder = m.coder
+		return
+	}
+	f, ok := m.coder.(SessionFactory)
+	if !ok {
+		e = ErrCoderNotMatched
+		return
+	}
+	coder, e = NewCoder(name, f.NewSession)
+	if e != nil {
+		return
+	}
+	converted = true
+	return
+}
+
+// 解碼 raw 中的 session。數據使用 raw 記錄的 Coder 解碼，舊版本的數據會先逐級升級到當前版本。
+// 啓用寫回時使用當前 Coder 重新編碼的數據會保存到 raw 中並返回 upgraded 爲 true，
+// 否則每次讀取都會重新執行升級
+func (m *Manager) unmarshalSession(raw *protoc_session.Raw) (session interface{}, upgraded bool, e error) {
+	coder, converted, e := m.decoder(raw.Coder)
+	if e != nil {
+		return
+	}
+	data := raw.Data
+	upgrade := m.opts.schema != nil && raw.Version != m.opts.schema.version
+	if upgrade {
+		data, e = m.opts.schema.Upgrade(raw.Coder, raw.Version, data)
+		if e != nil {
+			return
+		}
+	}
+	session, e = coder.Unmarshal(data)
+	if e != nil || !m.opts.writeBack || !(upgrade || converted) {
+		return
+	}
+	if converted {
+		data, e = m.coder.Marshal(session)
+		if e != nil {
+			return
+		}
+	}
+	raw.Data = data
+	raw.Coder = m.coderName()
+	raw.Version = m.schemaVersion()
+	upgraded = true
+	return
+}
+
+// 將升級後的 session 數據寫回存儲，寫回失敗不影響已經讀取的 session 所以忽略錯誤
+func (m *Manager) writeBack(ctx context.Context, access string) {
+	m.modifyRaw(ctx, access, func(key string, raw *protoc_session.Raw) (deadline time.Time, e error) {
+		_, upgraded, e := m.unmarshalSession(raw)
+		if e != nil {
+			return
+		} else if !upgraded {
+			e = errNotModified
+			return
+		}
+		deadline = time.Unix(raw.Token.RefreshDeadline, 0)
+		return
+	})
+}
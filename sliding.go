@@ -0,0 +1,103 @@
+package sessionstore
+
+import (
+	"context"
+	"time"
+
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
+)
+
+// 返回是否已經過了 access 有效期的 sliding 比例，需要延長有效期
+func (m *Manager) needSlide(token *Token, now time.Time) bool {
+	if m.opts.sliding <= 0 {
+		return false
+	}
+	access := m.opts.access
+	elapsed := now.Sub(time.Unix(token.AccessDeadline, 0).Add(-access))
+	return float64(elapsed) >= float64(access)*m.opts.sliding
+}
+
+// 返回是否需要記錄新的活動時間，爲了減少寫入 每 idle/10 或 WithLastSeen 設定的間隔 最多記錄一次
+func (m *Manager) needActive(raw *protoc_session.Raw, now time.Time) bool {
+	interval := m.opts.seen
+	if m.opts.idle > 0 && (interval <= 0 || m.opts.idle/10 < interval) {
+		interval = m.opts.idle / 10
+	}
+	if interval <= 0 {
+		return false
+	}
+	return now.Sub(time.Unix(raw.Active, 0)) >= interval
+}
+
+// 返回 session 是否已經超過 idle 時間沒有活動
+func (m *Manager) isIdle(raw *protoc_session.Raw, now time.Time) bool {
+	return m.opts.idle > 0 && raw.Active != 0 &&
+		now.Sub(time.Unix(raw.Active, 0)) > m.opts.idle
+}
+
+// 返回延長後的有效期，不會超過 deadline 也不會縮短原有效期
+func slideDeadline(current int64, to time.Time, deadline int64) int64 {
+	unix := to.Unix()
+	if deadline != 0 && unix > deadline {
+		unix = deadline
+	}
+	if unix < current {
+		unix = current
+	}
+	return unix
+}
+
+// 依據設定 延長 access 關聯 session 的有效期 並記錄活動時間，
+// 如果沒有寫入存儲 返回的 token 爲 nil
+func (m *Manager) touch(ctx context.Context, access string) (token *Token, e error) {
+	var (
+		slideKey string
+		slideRaw *protoc_session.Raw
+	)
+	e = m.modifyRaw(ctx, access, func(key string, raw *protoc_session.Raw) (deadline time.Time, e error) {
+		slideKey = ``
+		now := time.Now()
+		current := raw.Token
+		token = m.accessToken(raw, access)
+		if token.IsExpired() {
+			e = errNotModified
+			return
+		}
+		modified := m.needActive(raw, now)
+		// 只延長當前 token 的有效期，寬限期內的上一個 token 只記錄活動
+		if m.matchToken(current.Access, access) && m.needSlide(token, now) {
+			accessDeadline := slideDeadline(current.AccessDeadline, now.Add(m.opts.access), current.Deadline)
+			refreshDeadline := slideDeadline(current.RefreshDeadline, now.Add(m.opts.refresh), current.Deadline)
+			if accessDeadline != current.AccessDeadline || refreshDeadline != current.RefreshDeadline {
+				modified = true
+				token.AccessDeadline = accessDeadline
+				token.RefreshDeadline = refreshDeadline
+				current.AccessDeadline = accessDeadline
+				current.RefreshDeadline = refreshDeadline
+				slideKey = key
+				slideRaw = raw
+			}
+		}
+		if !modified {
+			e = errNotModified
+			return
+		}
+		raw.Active = now.Unix()
+		token.Metadata = newMetadata(raw.Metadata, raw.Active)
+		deadline = time.Unix(current.RefreshDeadline, 0)
+		return
+	})
+	if e == errNotModified || e == cryptoer.ErrConflict {
+		e = nil
+		token = nil
+		return
+	} else if e != nil || slideKey == `` || !m.indexed() {
+		return
+	}
+	// 和 Refresh 一樣延長索引的有效期，否則索引會在 session 之前過期
+	if prefix, id, ok := m.indexOf(slideKey, slideRaw); ok {
+		e = m.touchIndex(ctx, prefix, id, time.Unix(slideRaw.Token.RefreshDeadline, 0))
+	}
+	return
+}
@@ -0,0 +1,231 @@
+package sessionstore
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"time"
+
+	"github.com/powerpuffpenguin/sessionstore/cryptoer"
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
+	"google.golang.org/protobuf/proto"
+)
+
+// 無狀態模式下 token 加密時的附加數據，避免 access 和 refresh 互換使用
+var (
+	statelessAccess  = []byte(`access`)
+	statelessRefresh = []byte(`refresh`)
+)
+
+// 加密 session 並返回 token，token 爲 RawURLBase64(nonce+ciphertext)
+func (m *Manager) sealStateless(additional []byte, s *protoc_session.Stateless) (token string, e error) {
+	b, e := proto.Marshal(s)
+	if e != nil {
+		return
+	}
+	aead := m.opts.stateless
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(b)+aead.Overhead())
+	_, e = rand.Read(nonce)
+	if e != nil {
+		return
+	}
+	token = base64.RawURLEncoding.EncodeToString(aead.Seal(nonce, nonce, b, additional))
+	return
+}
+
+// 解密 token 並返回其中的 session
+func (m *Manager) openStateless(additional []byte, token string) (s *protoc_session.Stateless, e error) {
+	b, e := base64.RawURLEncoding.DecodeString(token)
+	if e != nil {
+		e = cryptoer.ErrInvalidToken
+		return
+	}
+	aead := m.opts.stateless
+	if len(b) < aead.NonceSize() {
+		e = cryptoer.ErrInvalidToken
+		return
+	}
+	b, e = aead.Open(nil, b[:aead.NonceSize()], b[aead.NonceSize():], additional)
+	if e != nil {
+		e = cryptoer.ErrInvalidToken
+		return
+	}
+	var tmp protoc_session.Stateless
+	e = proto.Unmarshal(b, &tmp)
+	if e != nil {
+		e = cryptoer.ErrInvalidToken
+		return
+	}
+	s = &tmp
+	return
+}
+
+// 簽發一組 access 和 refresh token
+func (m *Manager) statelessSeal(s *protoc_session.Stateless) (token *Token, e error) {
+	access, e := m.sealStateless(statelessAccess, s)
+	if e != nil {
+		return
+	}
+	refresh, e := m.sealStateless(statelessRefresh, s)
+	if e != nil {
+		return
+	}
+	token = NewToken(access, refresh,
+		s.AccessDeadline, s.RefreshDeadline,
+		s.Deadline,
+	)
+	token.Metadata = newMetadata(s.Metadata, 0)
+	return
+}
+
+func (m *Manager) statelessPut(ctx context.Context, id, platform string, session interface{}, opt []MetadataOption) (token *Token, e error) {
+	b, e := m.coder.Marshal(session)
+	if e != nil {
+		return
+	}
+	sid := make([]byte, 16)
+	_, e = rand.Read(sid)
+	if e != nil {
+		return
+	}
+	now := time.Now()
+	var deadline int64
+	if m.opts.deadline != 0 {
+		deadline = now.Add(m.opts.deadline).Unix()
+	}
+	token, e = m.statelessSeal(&protoc_session.Stateless{
+		Id:              id,
+		Platform:        platform,
+		Sid:             sid,
+		Issued:          now.UnixNano(),
+		AccessDeadline:  now.Add(m.opts.access).Unix(),
+		RefreshDeadline: now.Add(m.opts.refresh).Unix(),
+		Deadline:        deadline,
+		Data:            b,
+		Metadata:        m.applyMetadata(nil, now, opt),
+	})
+	return
+}
+
+// 解密 access token 並檢查其是否已經被撤銷
+func (m *Manager) statelessOpen(ctx context.Context, access string) (s *protoc_session.Stateless, token *Token, e error) {
+	s, e = m.openStateless(statelessAccess, access)
+	if e != nil {
+		return
+	}
+	token = NewToken(access, ``,
+		s.AccessDeadline, s.RefreshDeadline,
+		s.Deadline,
+	)
+	token.Metadata = newMetadata(s.Metadata, 0)
+	if token.IsDeleted() {
+		e = cryptoer.ErrNotExistsToken
+		return
+	}
+	revoked, e := m.isRevoked(ctx, s)
+	if e != nil {
+		return
+	} else if revoked {
+		e = cryptoer.ErrNotExistsToken
+		return
+	}
+	return
+}
+func (m *Manager) statelessGet(ctx context.Context, access string, opt []MetadataOption) (token *Token, session interface{}, e error) {
+	s, token, e := m.statelessOpen(ctx, access)
+	if e != nil {
+		return
+	} else if token.IsExpired() {
+		e = cryptoer.ErrExpired
+		return
+	}
+	e = m.verifyBinding(ctx, s.Metadata, opt)
+	if e != nil {
+		if e == cryptoer.ErrReauthRequired {
+			e = m.reauth(ctx, ``, s)
+		}
+		token = nil
+		return
+	}
+	session, e = m.coder.Unmarshal(s.Data)
+	return
+}
+func (m *Manager) statelessRefresh(ctx context.Context, access, refresh string, opt []MetadataOption) (token *Token, session interface{}, e error) {
+	s, token, e := m.statelessOpen(ctx, access)
+	if e != nil {
+		return
+	}
+	r, e := m.openStateless(statelessRefresh, refresh)
+	if e != nil {
+		e = cryptoer.ErrRefreshTokenNotMatched
+		token = nil
+		return
+	} else if string(r.Sid) != string(s.Sid) {
+		e = cryptoer.ErrRefreshTokenNotMatched
+		token = nil
+		return
+	} else if !token.CanRefresh() {
+		e = cryptoer.ErrCannotRefresh
+		token = nil
+		return
+	}
+	// 只有持有 refresh token 的請求才檢查綁定
+	e = m.verifyBinding(ctx, s.Metadata, opt)
+	if e != nil {
+		if e == cryptoer.ErrReauthRequired {
+			e = m.reauth(ctx, ``, s)
+		}
+		token = nil
+		return
+	}
+	session, e = m.coder.Unmarshal(s.Data)
+	if e != nil {
+		return
+	}
+
+	now := time.Now()
+	_, e = rand.Read(s.Sid)
+	if e != nil {
+		return
+	}
+	s.Issued = now.UnixNano()
+	s.AccessDeadline = now.Add(m.opts.access).Unix()
+	s.RefreshDeadline = now.Add(m.opts.refresh).Unix()
+	s.Metadata = m.applyMetadata(s.Metadata, now, opt)
+	s.Metadata.Refreshed = now.Unix()
+	token, e = m.statelessSeal(s)
+	if e != nil {
+		token = nil
+		session = nil
+	}
+	return
+}
+
+// 撤銷 key 下所有在當前時間之前簽發的 token
+func (m *Manager) statelessRevoke(ctx context.Context, key string) (e error) {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(time.Now().UnixNano()))
+	// 在此之前簽發的 token 最晚在 refresh 有效期後全部過期
+	e = m.opts.store.Put(ctx, key, b, time.Now().Add(m.opts.refresh))
+	return
+}
+
+// 返回 session 是否在 用戶 或 平臺 被撤銷之前簽發
+func (m *Manager) isRevoked(ctx context.Context, s *protoc_session.Stateless) (revoked bool, e error) {
+	keys := []string{
+		base64.RawURLEncoding.EncodeToString(StringToBytes(s.Id)),
+		encodeKey(s.Id, s.Platform),
+	}
+	for _, key := range keys {
+		var b []byte
+		b, e = m.opts.store.Get(ctx, key)
+		if e != nil {
+			return
+		} else if len(b) == 8 && s.Issued <= int64(binary.BigEndian.Uint64(b)) {
+			revoked = true
+			return
+		}
+	}
+	return
+}
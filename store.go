@@ -8,12 +8,31 @@ import (
 type Store interface {
 	// 設置數據
 	Put(ctx context.Context, key string, value []byte, deadline time.Time) (e error)
+	// 如果 key 的當前數據等於 old 則設置爲 value 並返回 true，如果 old 爲 nil 則只在 key 不存在時設置。
+	// deadline 已經過去時刪除 key
+	CompareAndSwap(ctx context.Context, key string, old, value []byte, deadline time.Time) (swapped bool, e error)
 	// 返回數據
 	Get(ctx context.Context, key string) (value []byte, e error)
 	// 刪除數據
 	Del(ctx context.Context, key string) (e error)
 	// 刪除指定前綴的數據
 	DelPrefix(ctx context.Context, prefix string) (e error)
+	// 遍歷指定前綴的數據，如果 f 返回 false 則停止遍歷
+	Range(ctx context.Context, prefix string, f func(key string, value []byte) bool) (e error)
 	// 關閉存儲設備 釋放相關資源
 	Close() (e error)
 }
+
+// 可選接口，讀寫分離的 Store 實現此接口從寫入節點返回最新的數據，
+// Manager 在 CompareAndSwap 之前使用它讀取，以免副本延遲導致不斷的衝突
+type LatestGetter interface {
+	GetLatest(ctx context.Context, key string) (value []byte, e error)
+}
+
+// 返回 CompareAndSwap 之前需要比較的數據，如果 s 實現了 LatestGetter 則使用它讀取
+func getLatest(ctx context.Context, s Store, key string) (value []byte, e error) {
+	if getter, ok := s.(LatestGetter); ok {
+		return getter.GetLatest(ctx, key)
+	}
+	return s.Get(ctx, key)
+}
@@ -1,10 +1,12 @@
 package bbolt
 
 import (
+	"bytes"
 	"context"
 	"time"
 
 	"github.com/powerpuffpenguin/sessionstore"
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
 	"github.com/powerpuffpenguin/sessionstore/store"
 	bolt "go.etcd.io/bbolt"
 )
@@ -43,45 +45,84 @@ func (s *Store) Put(ctx context.Context, key string, value []byte, deadline time
 	if expiration < time.Second {
 		return
 	}
-	err = s.opts.db.Update(func(t *bolt.Tx) (e error) {
-		bkey := sessionstore.StringToBytes(key)
+	err = s.opts.db.Update(func(t *bolt.Tx) error {
+		return s.put(t, sessionstore.StringToBytes(key), value, deadline)
+	})
+	return
+}
+func (s *Store) put(t *bolt.Tx, bkey, value []byte, deadline time.Time) (e error) {
+	bsystem, bdata, bsort := getBuckets(t)
+	if bdata == nil || bsort == nil || bsystem == nil {
+		bsystem, bdata, bsort, e = createBuckets(t)
+		if e != nil {
+			return
+		}
+	} else {
+		e = delKey(bsystem, bdata, bsort, bkey)
+		if e != nil {
+			return
+		}
+		e = popKey(bsystem, bdata, bsort)
+		if e != nil {
+			return
+		}
+	}
+	count := getCount(bsystem)
+	if count >= s.opts.limit {
+		e = store.ErrCapacityLimitReached
+		return
+	}
+
+	unix := deadline.Unix()
+
+	id, e := putSort(bsort, bkey, unix)
+	if e != nil {
+		return
+	}
+	e = putData(bdata, id, bkey, value, unix)
+	if e != nil {
+		return
+	}
 
+	e = setCount(bsystem, count+1)
+	return
+}
+
+// 如果 key 的當前數據等於 old 則設置爲 value 並返回 true，如果 old 爲 nil 則只在 key 不存在時設置。
+// deadline 已經過去時刪除 key
+func (s *Store) CompareAndSwap(ctx context.Context, key string, old, value []byte, deadline time.Time) (swapped bool, err error) {
+	err = s.opts.db.Update(func(t *bolt.Tx) (e error) {
 		bsystem, bdata, bsort := getBuckets(t)
-		if bdata == nil || bsort == nil || bsystem == nil {
-			bsystem, bdata, bsort, e = createBuckets(t)
-			if e != nil {
-				return
-			}
-		} else {
-			e = delKey(bsystem, bdata, bsort, bkey)
+		bkey := sessionstore.StringToBytes(key)
+		var data *protoc_session.BBoltData
+		if bsystem != nil && bdata != nil && bsort != nil {
+			data, e = getData(bdata, bkey)
 			if e != nil {
 				return
+			} else if data != nil && time.Now().Unix() > data.Deadline {
+				data = nil
 			}
-			e = popKey(bsystem, bdata, bsort)
-			if e != nil {
+		}
+		if old == nil {
+			if data != nil {
 				return
 			}
-		}
-		count := getCount(bsystem)
-		if count >= s.opts.limit {
-			e = store.ErrCapacityLimitReached
+		} else if data == nil || !bytes.Equal(data.Data, old) {
 			return
 		}
-
-		unix := deadline.Unix()
-
-		id, e := putSort(bsort, bkey, unix)
-		if e != nil {
-			return
-		}
-		e = putData(bdata, id, bkey, value, unix)
-		if e != nil {
-			return
+		if time.Until(deadline) < time.Second {
+			if data != nil {
+				e = delKey(bsystem, bdata, bsort, bkey)
+			}
+		} else {
+			e = s.put(t, bkey, value, deadline)
 		}
-
-		e = setCount(bsystem, count+1)
+		swapped = e == nil
 		return
 	})
+	if err != nil {
+		swapped = false
+	}
 	return
 }
 
@@ -132,6 +173,29 @@ func (s *Store) DelPrefix(ctx context.Context, prefix string) (err error) {
 	return
 }
 
+// 遍歷指定前綴的數據，如果 f 返回 false 則停止遍歷
+func (s *Store) Range(ctx context.Context, prefix string, f func(key string, value []byte) bool) (err error) {
+	var keys []string
+	var values [][]byte
+	err = s.opts.db.View(func(t *bolt.Tx) (e error) {
+		bdata := t.Bucket(bucketData)
+		if bdata == nil {
+			return
+		}
+		keys, values, e = rangeKeyPrefix(bdata, prefix)
+		return
+	})
+	if err != nil {
+		return
+	}
+	for i, key := range keys {
+		if !f(key, values[i]) {
+			break
+		}
+	}
+	return
+}
+
 func (s *Store) Close() (e error) {
 	e = s.opts.db.Close()
 	return
@@ -181,3 +181,26 @@ func delKeyPrefix(bsystem, bdata, bsort *bolt.Bucket, prefix string) (e error) {
 	}
 	return
 }
+func rangeKeyPrefix(bdata *bolt.Bucket, prefix string) (keys []string, values [][]byte, e error) {
+	var (
+		c    = bdata.Cursor()
+		bkey = sessionstore.StringToBytes(prefix)
+		now  = time.Now().Unix()
+	)
+	for k, v := c.Seek(bkey); k != nil && strings.HasPrefix(sessionstore.BytesToString(k), prefix); k, v = c.Next() {
+		if v == nil {
+			continue
+		}
+		var m protoc_session.BBoltData
+		e = proto.Unmarshal(v, &m)
+		if e != nil {
+			return
+		}
+		if now > m.Deadline {
+			continue
+		}
+		keys = append(keys, string(k))
+		values = append(values, m.Data)
+	}
+	return
+}
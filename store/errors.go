@@ -5,4 +5,7 @@ import "errors"
 var (
 	ErrCapacityLimitReached = errors.New(`store capacity limit reached`)
 	ErrClosed               = errors.New(`store already closed`)
+	ErrUnknownKeyVersion    = errors.New(`unknown encryption key version`)
+	ErrDecrypt              = errors.New(`store value decrypt failed`)
+	ErrNotSupported         = errors.New(`store operation not supported`)
 )
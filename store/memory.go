@@ -1,6 +1,7 @@
 package store
 
 import (
+	"bytes"
 	"container/list"
 	"context"
 	"strings"
@@ -86,6 +87,42 @@ func (m *Memory) Put(ctx context.Context, key string, value []byte, deadline tim
 	m.rw.Unlock()
 	return
 }
+
+// 如果 key 的當前數據等於 old 則設置爲 value 並返回 true，如果 old 爲 nil 則只在 key 不存在時設置。
+// deadline 已經過去時刪除 key
+func (m *Memory) CompareAndSwap(ctx context.Context, key string, old, value []byte, deadline time.Time) (swapped bool, e error) {
+	m.rw.Lock()
+	defer m.rw.Unlock()
+	if m.closed {
+		e = ErrClosed
+		return
+	}
+	ele, exists := m.keys[key]
+	if exists && ele.Value.(*_MemoryValue).IsDeleted() {
+		delete(m.keys, key)
+		m.list.Remove(ele)
+		exists = false
+	}
+	if old == nil {
+		if exists {
+			return
+		}
+	} else if !exists || !bytes.Equal(ele.Value.(*_MemoryValue).value, old) {
+		return
+	}
+	if time.Now().Before(deadline) {
+		e = m.put(key, &_MemoryValue{
+			key:      key,
+			value:    value,
+			deadline: deadline,
+		})
+	} else if exists {
+		delete(m.keys, key)
+		m.list.Remove(ele)
+	}
+	swapped = e == nil
+	return
+}
 func (m *Memory) pop() {
 	var (
 		now      = time.Now()
@@ -174,6 +211,31 @@ func (m *Memory) DelPrefix(ctx context.Context, prefix string) (e error) {
 	return
 }
 
+// 遍歷指定前綴的數據，如果 f 返回 false 則停止遍歷
+func (m *Memory) Range(ctx context.Context, prefix string, f func(key string, value []byte) bool) (e error) {
+	var values []*_MemoryValue
+	m.rw.RLock()
+	if m.closed {
+		e = ErrClosed
+	} else {
+		for key, ele := range m.keys {
+			if strings.HasPrefix(key, prefix) {
+				val := ele.Value.(*_MemoryValue)
+				if !val.IsDeleted() {
+					values = append(values, val)
+				}
+			}
+		}
+	}
+	m.rw.RUnlock()
+	for _, val := range values {
+		if !f(val.key, val.value) {
+			break
+		}
+	}
+	return
+}
+
 // 關閉存儲設備 釋放相關資源
 func (m *Memory) Close() (e error) {
 	m.rw.Lock()
@@ -156,3 +156,21 @@ func (m *Merge) Scan(ctx context.Context, cursor uint64, match string, count int
 		return <-done
 	}
 }
+func (m *Merge) Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd {
+	done := make(chan *redis.Cmd, 1)
+	select {
+	case <-m.ctx.Done():
+		var result redis.Cmd
+		result.SetErr(m.ctx.Err())
+		return &result
+	case m.ch <- &_MergeArgs{
+		run: func(pipeliner redis.Pipeliner) interface{} {
+			return pipeliner.Eval(ctx, script, keys, args...)
+		},
+		done: func(v interface{}) {
+			done <- v.(*redis.Cmd)
+		},
+	}:
+		return <-done
+	}
+}
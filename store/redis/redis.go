@@ -14,6 +14,12 @@ type Backend interface {
 	Scan(ctx context.Context, cursor uint64, match string, count int64) *redis.ScanCmd
 	Close() error
 }
+
+// 可以執行 lua 腳本的 Backend，CompareAndSwap 需要寫入 Backend 實現此接口，
+// redis.Client redis.ClusterClient 和 Merge 都實現了它
+type Evaler interface {
+	Eval(ctx context.Context, script string, keys []string, args ...interface{}) *redis.Cmd
+}
 type Redis interface {
 	Backend
 	Pipeline() redis.Pipeliner
@@ -3,7 +3,11 @@ package redis
 import (
 	"context"
 	"errors"
+	"strings"
 	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/powerpuffpenguin/sessionstore/store"
 )
 
 type Store struct {
@@ -38,9 +42,63 @@ func (s *Store) Put(ctx context.Context, key string, value []byte, deadline time
 	return
 }
 
+const scriptCompareAndSwap = `local current = redis.call('GET', KEYS[1])
+if ARGV[4] == '1' then
+	if current then
+		return 0
+	end
+elseif current ~= ARGV[1] then
+	return 0
+end
+if tonumber(ARGV[3]) > 0 then
+	redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+else
+	redis.call('DEL', KEYS[1])
+end
+return 1`
+
+// 如果 key 的當前數據等於 old 則設置爲 value 並返回 true，如果 old 爲 nil 則只在 key 不存在時設置。
+// deadline 已經過去時刪除 key
+func (s *Store) CompareAndSwap(ctx context.Context, key string, old, value []byte, deadline time.Time) (swapped bool, e error) {
+	expiration := time.Until(deadline)
+	if expiration < time.Second {
+		expiration = 0
+	}
+	evaler, ok := s.opts.write.(Evaler)
+	if !ok {
+		e = store.ErrNotSupported
+		return
+	}
+	absent := 0
+	if old == nil {
+		absent = 1
+	}
+	result, e := evaler.Eval(ctx, scriptCompareAndSwap, []string{key},
+		old, value, expiration.Milliseconds(), absent,
+	).Int()
+	if e != nil {
+		return
+	}
+	swapped = result == 1
+	return
+}
+
 // 返回數據
 func (s *Store) Get(ctx context.Context, key string) (value []byte, e error) {
-	return s.opts.read.Get(ctx, key).Bytes()
+	value, e = s.opts.read.Get(ctx, key).Bytes()
+	if e == redis.Nil {
+		e = nil
+	}
+	return
+}
+
+// 從寫入 Backend 返回最新的數據，在 CompareAndSwap 之前使用以免讀取副本的延遲導致衝突
+func (s *Store) GetLatest(ctx context.Context, key string) (value []byte, e error) {
+	value, e = s.opts.write.Get(ctx, key).Bytes()
+	if e == redis.Nil {
+		e = nil
+	}
+	return
 }
 
 // 刪除數據
@@ -49,7 +107,7 @@ func (s *Store) Del(ctx context.Context, key string) (e error) {
 }
 
 // 刪除指定前綴的數據
-func (s *Store) DelPrefix(ctx context.Context, prefix string) (e error){
+func (s *Store) DelPrefix(ctx context.Context, prefix string) (e error) {
 	var (
 		cursor uint64
 		count  int64 = 1000
@@ -80,6 +138,56 @@ func (s *Store) DelPrefix(ctx context.Context, prefix string) (e error){
 	}
 	return
 }
+
+// 遍歷指定前綴的數據，如果 f 返回 false 則停止遍歷
+func (s *Store) Range(ctx context.Context, prefix string, f func(key string, value []byte) bool) (e error) {
+	var (
+		cursor uint64
+		count  int64 = 1000
+		match        = escapePattern(prefix) + "*"
+		keys   []string
+		value  []byte
+	)
+	for {
+		keys, cursor, e = s.opts.read.Scan(ctx, cursor,
+			match,
+			count,
+		).Result()
+		if e != nil {
+			return
+		}
+		for _, key := range keys {
+			value, e = s.opts.read.Get(ctx, key).Bytes()
+			if e == redis.Nil {
+				e = nil
+				continue
+			} else if e != nil {
+				return
+			}
+			if !f(key, value) {
+				return
+			}
+		}
+		if cursor == 0 {
+			break
+		}
+	}
+	return
+}
+
+// 轉義 redis glob 模式中的特殊字符
+func escapePattern(s string) string {
+	var builder strings.Builder
+	for _, r := range s {
+		switch r {
+		case '*', '?', '[', ']', '\\':
+			builder.WriteByte('\\')
+		}
+		builder.WriteRune(r)
+	}
+	return builder.String()
+}
+
 // 關閉存儲設備 釋放相關資源
 func (s *Store) Close() (e error) {
 	e = s.opts.write.Close()
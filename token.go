@@ -10,6 +10,8 @@ type Token struct {
 	AccessDeadline  int64
 	RefreshDeadline int64
 	Deadline        int64
+	// session 元數據，只在從 Manager 返回時設置
+	Metadata *Metadata
 }
 
 func NewToken(access, refresh string,
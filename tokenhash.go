@@ -0,0 +1,46 @@
+package sessionstore
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+
+	protoc_session "github.com/powerpuffpenguin/sessionstore/sessionstore/session"
+)
+
+// 返回 token 在存儲中的形式，啓用 WithTokenHash 時爲 token 的 HMAC-SHA256
+func (m *Manager) storedToken(token string) string {
+	if m.opts.hash == nil {
+		return token
+	}
+	hasher := hmac.New(sha256.New, m.opts.hash)
+	hasher.Write(StringToBytes(token))
+	return base64.RawURLEncoding.EncodeToString(hasher.Sum(nil))
+}
+
+// 以常數時間比較 存儲的 token 和 調用者提供的 token
+func (m *Manager) matchToken(stored, token string) bool {
+	return subtle.ConstantTimeCompare(StringToBytes(stored), StringToBytes(m.storedToken(token))) == 1
+}
+
+// 由存儲記錄創建返回給調用者的 Token。
+// 啓用 WithTokenHash 時存儲中只有 token 的哈希值，
+// 此時 Access 爲調用者提供的 access(如果它是當前 token) Refresh 爲空字符串
+func (m *Manager) rawToken(raw *protoc_session.Raw, access string) *Token {
+	token := NewToken(
+		raw.Token.Access, raw.Token.Refresh,
+		raw.Token.AccessDeadline, raw.Token.RefreshDeadline,
+		raw.Token.Deadline,
+	)
+	token.Metadata = newMetadata(raw.Metadata, raw.Active)
+	if m.opts.hash != nil {
+		if access != `` && m.matchToken(raw.Token.Access, access) {
+			token.Access = access
+		} else {
+			token.Access = ``
+		}
+		token.Refresh = ``
+	}
+	return token
+}
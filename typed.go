@@ -0,0 +1,193 @@
+package sessionstore
+
+import "context"
+
+// 編碼 *T 類型的 session
+type TypedCoder[T any] interface {
+	Unmarshal(b []byte) (session *T, e error)
+	Marshal(session *T) (b []byte, e error)
+}
+
+// 將 TypedCoder 適配爲 Coder
+type typedCoder[T any] struct {
+	coder TypedCoder[T]
+}
+
+func (c typedCoder[T]) Name() string {
+	if coder, ok := c.coder.(interface{ Name() string }); ok {
+		return coder.Name()
+	}
+	return ``
+}
+func (typedCoder[T]) NewSession() interface{} {
+	return new(T)
+}
+func (c typedCoder[T]) Unmarshal(b []byte) (session interface{}, e error) {
+	s, e := c.coder.Unmarshal(b)
+	if e != nil {
+		return
+	}
+	session = s
+	return
+}
+func (c typedCoder[T]) Marshal(session interface{}) (b []byte, e error) {
+	s, ok := session.(*T)
+	if !ok {
+		e = ErrSessionType
+		return
+	}
+	b, e = c.coder.Marshal(s)
+	return
+}
+
+// 一個活躍的 *T 類型 session
+type TypedElement[T any] struct {
+	// 登入平臺
+	Platform string
+	Token    *Token
+	Session  *T
+}
+
+// Manager 的泛型包裝，session 以 *T 類型傳入和返回而不需要類型斷言
+type TypedManager[T any] struct {
+	m *Manager
+}
+
+// 創建一個 session 類型爲 *T 的 TypedManager
+func NewTyped[T any](coder TypedCoder[T], opt ...Option) *TypedManager[T] {
+	return &TypedManager[T]{
+		m: New(typedCoder[T]{coder: coder}, opt...),
+	}
+}
+
+// 包裝一個已有的 Manager，其 Coder 返回的 session 必須是 *T 類型，否則返回 ErrSessionType
+func Typed[T any](m *Manager) *TypedManager[T] {
+	return &TypedManager[T]{
+		m: m,
+	}
+}
+
+// 返回被包裝的 Manager，以使用非泛型的 api
+func (t *TypedManager[T]) Manager() *Manager {
+	return t.m
+}
+func (t *TypedManager[T]) Close() error {
+	return t.m.Close()
+}
+
+// 返回 token 關聯的 session 數據
+func (t *TypedManager[T]) Get(ctx context.Context, access string, opt ...MetadataOption) (token *Token, session *T, e error) {
+	token, s, e := t.m.Get(ctx, access, opt...)
+	if e != nil {
+		return
+	}
+	session, e = typedSession[T](s)
+	if e != nil {
+		token = nil
+	}
+	return
+}
+
+// 更新 token 關聯的 session 數據，token 和 過期時間 保持不變
+func (t *TypedManager[T]) Update(ctx context.Context, access string, session *T) (e error) {
+	e = t.m.Update(ctx, access, session)
+	return
+}
+
+// 以 f 的返回值 原子的替換 token 關聯的 session 數據，參考 Manager.Modify
+func (t *TypedManager[T]) Modify(ctx context.Context, access string, f func(session *T) (*T, error)) (session *T, e error) {
+	s, e := t.m.Modify(ctx, access, func(s interface{}) (interface{}, error) {
+		session, e := typedSession[T](s)
+		if e != nil {
+			return nil, e
+		}
+		return f(session)
+	})
+	if e != nil {
+		return
+	}
+	session, e = typedSession[T](s)
+	return
+}
+
+// 刪除 token
+func (t *TypedManager[T]) Delete(ctx context.Context, access string) (e error) {
+	e = t.m.Delete(ctx, access)
+	return
+}
+
+// 刪除指定 用戶 id 的所有 session
+func (t *TypedManager[T]) DeleteID(ctx context.Context, id string) (e error) {
+	e = t.m.DeleteID(ctx, id)
+	return
+}
+
+// 刪除指定用戶 id 在 指定平臺 platform 的所有 session
+func (t *TypedManager[T]) DeletePlatform(ctx context.Context, id, platform string) (e error) {
+	e = t.m.DeletePlatform(ctx, id, platform)
+	return
+}
+
+// 創建 session 關聯的 token
+func (t *TypedManager[T]) Put(ctx context.Context, id, platform string, session *T, opt ...MetadataOption) (token *Token, e error) {
+	token, e = t.m.Put(ctx, id, platform, session, opt...)
+	return
+}
+
+// 使用 refresh token 簽發一組新的 token，opt 可以更新 session 元數據
+func (t *TypedManager[T]) Refresh(ctx context.Context, access, refresh string, opt ...MetadataOption) (token *Token, session *T, e error) {
+	token, s, e := t.m.Refresh(ctx, access, refresh, opt...)
+	if e != nil {
+		return
+	}
+	session, e = typedSession[T](s)
+	if e != nil {
+		token = nil
+	}
+	return
+}
+
+// 返回指定用戶 id 的所有 session
+func (t *TypedManager[T]) List(ctx context.Context, id string) (elements []*TypedElement[T], e error) {
+	items, e := t.m.List(ctx, id)
+	if e != nil {
+		return
+	}
+	elements, e = typedElements[T](items)
+	return
+}
+
+// 返回指定用戶 id 在 指定平臺 platform 的所有 session
+func (t *TypedManager[T]) ListPlatform(ctx context.Context, id, platform string) (elements []*TypedElement[T], e error) {
+	items, e := t.m.ListPlatform(ctx, id, platform)
+	if e != nil {
+		return
+	}
+	elements, e = typedElements[T](items)
+	return
+}
+
+func typedSession[T any](s interface{}) (session *T, e error) {
+	session, ok := s.(*T)
+	if !ok {
+		e = ErrSessionType
+	}
+	return
+}
+func typedElements[T any](items []*Element) (elements []*TypedElement[T], e error) {
+	elements = make([]*TypedElement[T], len(items))
+	for i, item := range items {
+		var session *T
+		session, e = typedSession[T](item.Session)
+		if e != nil {
+			elements = nil
+			return
+		}
+		elements[i] = &TypedElement[T]{
+			Platform: item.Platform,
+			Token:    item.Token,
+			Session:  session,
+		}
+	}
+	return
+}